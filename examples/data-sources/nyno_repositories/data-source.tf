@@ -0,0 +1,16 @@
+data "nyno_repositories" "services" {
+  is_active  = true
+  name_regex = "^svc-"
+  url_prefix = "https://github.com/nyno-app/"
+}
+
+resource "nyno_template" "service" {
+  for_each = { for repository in data.nyno_repositories.services.repositories : repository.name => repository }
+
+  # ...
+
+  action {
+    # ...
+    repository_id = each.value.id
+  }
+}
@@ -0,0 +1,3 @@
+data "nyno_repository" "example" {
+  url = "git@github.com:nyno-app/example.git"
+}
@@ -0,0 +1,4 @@
+import {
+  to = nyno_credentials.example
+  id = "name:example"
+}
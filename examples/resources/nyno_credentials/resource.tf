@@ -0,0 +1,9 @@
+resource "nyno_credentials" "github" {
+  name     = "github-bot"
+  type     = "github"
+  username = "nyno-bot"
+  secret   = var.github_token
+
+  # Change along with the secret to push it to nyno.
+  rotation_trigger = "2024-06"
+}
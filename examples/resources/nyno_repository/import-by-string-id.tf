@@ -0,0 +1,4 @@
+import {
+  to = nyno_repository.example
+  id = "name:example"
+}
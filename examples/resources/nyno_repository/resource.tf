@@ -0,0 +1,13 @@
+resource "nyno_repository" "example" {
+  name = "example"
+  url  = "https://github.com/nyno-app/example"
+}
+
+resource "nyno_template" "example" {
+  # ...
+
+  action {
+    # ...
+    repository_id = nyno_repository.example.id
+  }
+}
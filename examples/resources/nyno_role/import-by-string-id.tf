@@ -0,0 +1,4 @@
+import {
+  to = nyno_role.example
+  id = "name:example"
+}
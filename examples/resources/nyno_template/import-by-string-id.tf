@@ -0,0 +1,4 @@
+import {
+  to = nyno_template.example
+  id = "name:example"
+}
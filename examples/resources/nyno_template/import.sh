@@ -0,0 +1,5 @@
+# By ID
+terraform import nyno_template.example 8d4c3a7e-1f2b-4c5d-9e6f-0a1b2c3d4e5f
+
+# By name, which must match exactly one template
+terraform import nyno_template.example name:example
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials is returned by Login when the API rejects the
+	// username or password.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrUnknownOrganization is returned by Login when the organization does
+	// not exist on the Nyno instance.
+	ErrUnknownOrganization = errors.New("unknown organization")
+)
+
+// UnreachableError is returned by Login when no response could be obtained
+// from the API endpoint.
+type UnreachableError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("unable to reach %s: %s", e.Endpoint, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by Login when the API answered successfully but the
+// response does not carry a session token.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode login response: %s", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+type credentials struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Organization string `json:"organization"`
+}
+
+type loginResponse struct {
+	SessionToken string    `json:"sessionToken"`
+	Expires      time.Time `json:"expires"`
+}
+
+// Login exchanges the credentials for a session token, which is used by every
+// subsequent call of the client. The credentials are kept so that the session
+// can be renewed when it expires. With a session cache, a session cached for
+// the same password is reused without calling the API.
+func (c *Client) Login(ctx context.Context, username string, password string, organization string) error {
+	ctx = logContext(ctx)
+	c.redactor.add(password)
+
+	creds := &credentials{
+		Username:     username,
+		Password:     password,
+		Organization: organization,
+	}
+
+	token, ok := c.cachedSession(ctx, creds)
+	if !ok {
+		var expires time.Time
+		var err error
+
+		token, expires, err = c.login(ctx, creds)
+		if err != nil {
+			return err
+		}
+		c.cacheSession(ctx, creds, token, expires)
+	}
+	c.redactor.add(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessionToken = token
+	c.credentials = creds
+	return nil
+}
+
+func (c *Client) cachedSession(ctx context.Context, creds *credentials) (string, bool) {
+	if c.sessionCache == nil {
+		return "", false
+	}
+
+	token, ok := c.sessionCache.get(sessionKey(c.endpoint, creds.Organization, creds.Username), creds.Password)
+	if ok {
+		c.logDebug(ctx, "Reusing cached Nyno session", nil)
+	}
+	return token, ok
+}
+
+func (c *Client) cacheSession(ctx context.Context, creds *credentials, token string, expires time.Time) {
+	if c.sessionCache == nil {
+		return
+	}
+
+	if expires.IsZero() {
+		expires = time.Now().Add(defaultSessionLifetime)
+	}
+
+	if err := c.sessionCache.put(sessionKey(c.endpoint, creds.Organization, creds.Username), creds.Password, token, expires); err != nil {
+		c.logWarn(ctx, "Unable to write the Nyno session cache", map[string]interface{}{"error": err})
+	}
+}
+
+func (c *Client) uncacheSession(ctx context.Context, creds *credentials) {
+	if c.sessionCache == nil {
+		return
+	}
+
+	if err := c.sessionCache.remove(sessionKey(c.endpoint, creds.Organization, creds.Username)); err != nil {
+		c.logWarn(ctx, "Unable to write the Nyno session cache", map[string]interface{}{"error": err})
+	}
+}
+
+// login obtains a new session and returns its token and, when the API tells,
+// its expiry.
+func (c *Client) login(ctx context.Context, creds *credentials) (string, time.Time, error) {
+	ctx = withRequestID(ctx)
+
+	// Logging in twice does no harm, so the login is retried like an
+	// idempotent request.
+	r, err := c.sendWithRetry(ctx, true, func() (*http.Request, error) {
+		return c.newRequest(ctx, http.MethodPost, "/auth/credentials", creds)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", time.Time{}, ctx.Err()
+		}
+		return "", time.Time{}, &UnreachableError{Endpoint: c.endpoint, Err: err}
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		respErr := decodeError(r)
+
+		switch r.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return "", time.Time{}, wrapLoginError(ErrInvalidCredentials, respErr)
+		case http.StatusNotFound:
+			return "", time.Time{}, wrapLoginError(ErrUnknownOrganization, respErr)
+		}
+		return "", time.Time{}, respErr
+	}
+
+	var response loginResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return "", time.Time{}, &DecodeError{Err: err}
+	}
+	if response.SessionToken == "" {
+		return "", time.Time{}, &DecodeError{Err: errors.New("response does not contain a session token")}
+	}
+
+	return response.SessionToken, response.Expires, nil
+}
+
+func wrapLoginError(sentinel error, respErr *ResponseError) error {
+	return fmt.Errorf("%w (%s)", sentinel, respErr)
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookieName = "next-auth.session-token"
+
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// Client talks to the Nyno API on behalf of a single provider instance.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+
+	// transport is shared by the login and every other request, so that TLS
+	// and connection settings apply to all of them.
+	transport *http.Transport
+
+	userAgent    string
+	extraHeaders map[string]string
+
+	// mu guards the session, which is shared by every goroutine Terraform
+	// runs for the provider instance.
+	mu           sync.Mutex
+	sessionToken string
+	credentials  *credentials
+
+	// apiToken replaces the session when set, see UseAPIToken.
+	apiToken string
+
+	sessionCache *SessionCache
+
+	maxRetries   int
+	retryMaxWait time.Duration
+
+	rateLimiter *rateLimiter
+
+	redactor  redactor
+	debugHTTP bool
+}
+
+// ResponseError is returned for every non-200 answer of the Nyno API.
+type ResponseError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+
+	// RequestID is the X-Request-ID sent by the client, ServerRequestID the
+	// request ID returned by the server when it differs.
+	RequestID       string `json:"-"`
+	ServerRequestID string `json:"-"`
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("Status Code: %v", e.StatusCode)
+	if e.Message != "" {
+		msg += fmt.Sprintf(". Message: %s", e.Message)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(". Request ID: %s", e.RequestID)
+	}
+	if e.ServerRequestID != "" {
+		msg += fmt.Sprintf(". Server Request ID: %s", e.ServerRequestID)
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 answer of the API, e.g. for an
+// object deleted outside of Terraform.
+func IsNotFound(err error) bool {
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// New returns a client for the API at endpoint. Login or UseAPIToken must be
+// called before any other method.
+func New(endpoint string) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	return &Client{
+		endpoint: endpoint,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: transport,
+		},
+		transport:    transport,
+		maxRetries:   DefaultMaxRetries,
+		retryMaxWait: DefaultRetryMaxWait,
+	}
+}
+
+// newRequest builds a request to path, encoding in as the JSON body when it is
+// not nil.
+func (c *Client) newRequest(ctx context.Context, method string, path string, in interface{}) (*http.Request, error) {
+	var body io.Reader
+	if in != nil {
+		requestBody, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(requestBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range c.extraHeaders {
+		req.Header.Set(name, value)
+	}
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// do sends a request to path, encoding in as the JSON body when it is not nil
+// and decoding the response into out when it is not nil. A request rejected
+// with 401 is replayed once after the session has been renewed.
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	ctx = withRequestID(logContext(ctx))
+	token := c.token()
+
+	r, err := c.send(ctx, method, path, in, token)
+	if err != nil {
+		return err
+	}
+
+	if r.StatusCode == http.StatusUnauthorized && c.canRefresh() {
+		r.Body.Close()
+
+		if err := c.refreshSession(ctx, token); err != nil {
+			return err
+		}
+
+		r, err = c.send(ctx, method, path, in, c.token())
+		if err != nil {
+			return err
+		}
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return decodeError(r)
+	}
+
+	if out == nil {
+		_, err = io.Copy(ioutil.Discard, r.Body)
+		return err
+	}
+
+	return json.NewDecoder(r.Body).Decode(out)
+}
+
+// send performs a request authenticated with token, retrying it according to
+// the retry policy.
+func (c *Client) send(ctx context.Context, method string, path string, in interface{}, token string) (*http.Response, error) {
+	return c.sendWithRetry(ctx, isIdempotent(method), func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, method, path, in)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.apiToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.apiToken)
+		} else {
+			req.Header.Set("Cookie", fmt.Sprintf("%s=%s", sessionCookieName, token))
+		}
+
+		return req, nil
+	})
+}
+
+// SetRequestTimeout bounds every single request, including reading its
+// response. Retries get a fresh timeout each. A timeout that is not positive
+// falls back to DefaultRequestTimeout rather than disabling the timeout.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	c.httpClient.Timeout = timeout
+}
+
+// SetTLSConfig sets the TLS settings used to connect to the API, such as a
+// custom CA bundle or a client certificate.
+func (c *Client) SetTLSConfig(tlsConfig *tls.Config) {
+	c.transport.TLSClientConfig = tlsConfig
+}
+
+// SetProxy sends every request through the proxy at proxyURL instead of the
+// one set in the HTTP_PROXY and HTTPS_PROXY environment variables.
+func (c *Client) SetProxy(proxyURL *url.URL) {
+	c.transport.Proxy = http.ProxyURL(proxyURL)
+}
+
+// SetUserAgent sets the User-Agent header of every request.
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// SetExtraHeaders adds headers to every request. They cannot replace the
+// headers carrying the credentials.
+func (c *Client) SetExtraHeaders(headers map[string]string) {
+	c.extraHeaders = headers
+}
+
+// UseSessionCache makes Login reuse sessions stored in cache and store the
+// sessions it obtains there.
+func (c *Client) UseSessionCache(cache *SessionCache) {
+	c.sessionCache = cache
+}
+
+// UseAPIToken authenticates every request with token as a bearer credential
+// instead of a session obtained through Login.
+func (c *Client) UseAPIToken(token string) {
+	c.apiToken = token
+	c.redactor.add(token)
+}
+
+func (c *Client) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sessionToken
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.credentials != nil
+}
+
+// refreshSession logs in again unless another goroutine already replaced
+// staleToken while this one was waiting for the lock, so that concurrent
+// requests failing with the same expired session trigger a single login.
+func (c *Client) refreshSession(ctx context.Context, staleToken string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sessionToken != staleToken {
+		return nil
+	}
+
+	c.logInfo(ctx, "Nyno session expired, logging in again", nil)
+	c.uncacheSession(ctx, c.credentials)
+
+	token, expires, err := c.login(ctx, c.credentials)
+	if err != nil {
+		return fmt.Errorf("unable to renew the Nyno session: %w", err)
+	}
+
+	c.sessionToken = token
+	c.redactor.add(token)
+	c.cacheSession(ctx, c.credentials, token, expires)
+	return nil
+}
+
+// decodeError builds a ResponseError from r, keeping the status code even when
+// the body is not in the API's error format.
+func decodeError(r *http.Response) *ResponseError {
+	respErr := &ResponseError{}
+	_ = json.NewDecoder(r.Body).Decode(respErr)
+	respErr.StatusCode = r.StatusCode
+
+	if r.Request != nil {
+		respErr.RequestID = r.Request.Header.Get(requestIDHeader)
+	}
+	for _, header := range serverRequestIDHeaders {
+		if id := r.Header.Get(header); id != "" && id != respErr.RequestID {
+			respErr.ServerRequestID = id
+			break
+		}
+	}
+
+	return respErr
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetRequestTimeout(t *testing.T) {
+	for timeout, expected := range map[time.Duration]time.Duration{
+		5 * time.Second:  5 * time.Second,
+		0:                DefaultRequestTimeout,
+		-1 * time.Second: DefaultRequestTimeout,
+	} {
+		c := New("https://nyno.example.com")
+		c.SetRequestTimeout(timeout)
+		if c.httpClient.Timeout != expected {
+			t.Errorf("timeout %s: expected %s, got %s", timeout, expected, c.httpClient.Timeout)
+		}
+	}
+}
+
+// TestRequestID follows a call through a retry, a renewal of its session and
+// the replay of the request, and checks that every attempt carries the same
+// X-Request-ID, which the error reports along with the ID of the gateway.
+func TestRequestID(t *testing.T) {
+	var mu sync.Mutex
+	var loginIDs, roleIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		id := r.Header.Get(requestIDHeader)
+		if r.URL.Path == "/auth/credentials" {
+			loginIDs = append(loginIDs, id)
+			fmt.Fprintf(w, `{"sessionToken": "session-%d"}`, len(loginIDs))
+			return
+		}
+
+		roleIDs = append(roleIDs, id)
+		switch {
+		case len(roleIDs) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.Contains(r.Header.Get("Cookie"), "session-1"):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Header().Set("X-Correlation-ID", "gateway-id")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "role not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	c.SetRetryPolicy(DefaultMaxRetries, time.Millisecond)
+	if err := c.Login(context.Background(), "nyno-bot", "password", "nyno"); err != nil {
+		t.Fatalf("login: %s", err)
+	}
+
+	_, err := c.GetRole(context.Background(), "1")
+	respErr, ok := err.(*ResponseError)
+	if !ok || respErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 ResponseError, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 503, then 401, then the replay after the renewal.
+	if len(roleIDs) != 3 || roleIDs[0] == "" || roleIDs[1] != roleIDs[0] || roleIDs[2] != roleIDs[0] {
+		t.Fatalf("expected the same request ID on the 3 attempts, got %q", roleIDs)
+	}
+	if len(loginIDs) != 2 || loginIDs[0] == "" || loginIDs[1] == "" || loginIDs[0] == loginIDs[1] || loginIDs[1] == roleIDs[0] {
+		t.Fatalf("expected each login to have its own request ID, got %q for %q", loginIDs, roleIDs[0])
+	}
+
+	expected := "Status Code: 404. Message: role not found. Request ID: " + roleIDs[0] + ". Server Request ID: gateway-id"
+	if err.Error() != expected {
+		t.Fatalf("expected the error %q, got %q", expected, err)
+	}
+}
+
+func TestDecodeError_echoedRequestID(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://nyno.example.com/roles/1", nil)
+	req.Header.Set(requestIDHeader, "client-id")
+
+	// A server echoing the ID of the client adds nothing to report.
+	r := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{requestIDHeader: {"client-id"}},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+	if err := decodeError(r); err.Error() != "Status Code: 500. Request ID: client-id" {
+		t.Fatalf("unexpected error %q", err)
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Credential lets Nyno act on Git repositories on behalf of the organization.
+// The API never returns Secret.
+type Credential struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Secret   string `json:"secret,omitempty"`
+}
+
+// CredentialValidation is the outcome of trying a credential against its Git
+// provider.
+type CredentialValidation struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message"`
+}
+
+func (c *Client) CreateCredential(ctx context.Context, credential *Credential) (*Credential, error) {
+	c.redactor.add(credential.Secret)
+
+	var response Credential
+	if err := c.do(ctx, http.MethodPost, "/credentials", credential, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetCredential(ctx context.Context, id string) (*Credential, error) {
+	var response Credential
+	if err := c.do(ctx, http.MethodGet, "/credentials/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListCredentials returns every credential of the organization.
+func (c *Client) ListCredentials(ctx context.Context) ([]*Credential, error) {
+	var credentials []*Credential
+	err := c.list(ctx, "/credentials", func(items json.RawMessage) error {
+		var page []*Credential
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		credentials = append(credentials, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// UpdateCredential replaces a credential. An empty Secret keeps the stored
+// one.
+func (c *Client) UpdateCredential(ctx context.Context, credential *Credential) (*Credential, error) {
+	c.redactor.add(credential.Secret)
+
+	var response Credential
+	if err := c.do(ctx, http.MethodPut, "/credentials/"+url.PathEscape(credential.ID), credential, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) DeleteCredential(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/credentials/"+url.PathEscape(id), nil, nil)
+}
+
+// ValidateCredential asks Nyno to try the credential with id against its Git
+// provider.
+func (c *Client) ValidateCredential(ctx context.Context, id string) (*CredentialValidation, error) {
+	var response CredentialValidation
+	if err := c.do(ctx, http.MethodPost, "/credentials/"+url.PathEscape(id)+"/validate", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// maxDumpFieldLength truncates long JSON strings, such as the base64
+	// template_code of an action, in HTTP dumps.
+	maxDumpFieldLength = 512
+
+	// maxDumpBodyLength truncates bodies that are not JSON.
+	maxDumpBodyLength = 16 * 1024
+)
+
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFieldParts marks a JSON field as sensitive when its lowercased name
+// contains one of them, e.g. password or sessionToken.
+var sensitiveFieldParts = []string{"password", "secret", "token"}
+
+// SetDebugHTTP makes the request and response dumps visible at DEBUG level
+// instead of TRACE.
+func (c *Client) SetDebugHTTP(enabled bool) {
+	c.debugHTTP = enabled
+}
+
+// dumpsLogged reports whether the dumps would make it to the log at all, so
+// that bodies are neither read nor scrubbed for nothing.
+func (c *Client) dumpsLogged() bool {
+	if c.debugHTTP {
+		return logLevelEnabled(hclog.Debug)
+	}
+	return logLevelEnabled(hclog.Trace)
+}
+
+func (c *Client) logDump(ctx context.Context, msg string, fields map[string]interface{}) {
+	if c.debugHTTP {
+		c.logDebug(ctx, msg, fields)
+		return
+	}
+	c.logTrace(ctx, msg, fields)
+}
+
+func (c *Client) dumpRequest(ctx context.Context, req *http.Request) {
+	if !c.dumpsLogged() {
+		return
+	}
+
+	body := ""
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			body = scrubBody(data)
+		}
+	}
+
+	c.logDump(ctx, "Nyno API request dump", map[string]interface{}{
+		"method":  req.Method,
+		"path":    req.URL.Path,
+		"headers": c.scrubHeaders(req.Header),
+		"body":    body,
+	})
+}
+
+// dumpResponse logs r and puts back its body for the caller to read.
+func (c *Client) dumpResponse(ctx context.Context, req *http.Request, r *http.Response) {
+	if !c.dumpsLogged() {
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	c.logDump(ctx, "Nyno API response dump", map[string]interface{}{
+		"method":  req.Method,
+		"path":    req.URL.Path,
+		"status":  r.StatusCode,
+		"headers": c.scrubHeaders(r.Header),
+		"body":    scrubBody(data),
+	})
+}
+
+// scrubHeaders masks the headers carrying credentials and every extra header,
+// which may well carry some too, e.g. the API key of a gateway.
+func (c *Client) scrubHeaders(header http.Header) string {
+	extra := make(map[string]bool, len(c.extraHeaders))
+	for name := range c.extraHeaders {
+		extra[http.CanonicalHeaderKey(name)] = true
+	}
+
+	lines := make([]string, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || extra[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// scrubBody masks the sensitive fields of a JSON body and truncates its long
+// strings. Other bodies are only truncated.
+func scrubBody(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return truncate(string(data), maxDumpBodyLength)
+	}
+
+	scrubbed, err := json.Marshal(scrubValue(body))
+	if err != nil {
+		return truncate(string(data), maxDumpBodyLength)
+	}
+
+	return string(scrubbed)
+}
+
+func scrubValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = redacted
+			} else {
+				v[key] = scrubValue(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = scrubValue(item)
+		}
+		return v
+	case string:
+		return truncate(v, maxDumpFieldLength)
+	default:
+		return v
+	}
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveFieldParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", s[:max], len(s)-max)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestScrubHeaders(t *testing.T) {
+	c := New("https://nyno.example.com")
+	c.SetExtraHeaders(map[string]string{"x-gateway-key": "gateway-secret"})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer api-token")
+	header.Set("Cookie", "next-auth.session-token=session")
+	header.Set("X-Gateway-Key", "gateway-secret")
+	header.Set("Content-Type", "application/json")
+
+	got := c.scrubHeaders(header)
+	for _, secret := range []string{"api-token", "session", "gateway-secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("scrubbed headers contain %q:\n%s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "X-Gateway-Key: ***") {
+		t.Errorf("expected the extra header to be masked:\n%s", got)
+	}
+	if !strings.Contains(got, "Content-Type: application/json") {
+		t.Errorf("expected Content-Type to be kept:\n%s", got)
+	}
+}
+
+func TestScrubBody(t *testing.T) {
+	got := scrubBody([]byte(`{
+		"username": "nyno-bot",
+		"password": "hunter2",
+		"sessionToken": "session-1",
+		"credential": {"name": "github", "secret": "ghp_secret"},
+		"credentials": [{"name": "gitlab", "secret": "glpat_secret"}, {"apiToken": "api-token"}]
+	}`))
+
+	for _, secret := range []string{"hunter2", "session-1", "ghp_secret", "glpat_secret", "api-token"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("scrubbed body contains %q: %s", secret, got)
+		}
+	}
+	for _, kept := range []string{`"username":"nyno-bot"`, `"name":"github"`, `"name":"gitlab"`, `"password":"***"`, `"secret":"***"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("expected the scrubbed body to contain %s: %s", kept, got)
+		}
+	}
+}
+
+func TestScrubBody_truncates(t *testing.T) {
+	templateCode := strings.Repeat("A", maxDumpFieldLength+100)
+	got := scrubBody([]byte(`{"actions": [{"name": "deploy", "templateCode": "` + templateCode + `"}]}`))
+	if strings.Contains(got, templateCode) || !strings.Contains(got, strings.Repeat("A", maxDumpFieldLength)+"... (100 bytes truncated)") {
+		t.Errorf("expected templateCode to be truncated to %d bytes: %s", maxDumpFieldLength, got)
+	}
+	if !strings.Contains(got, `"name":"deploy"`) {
+		t.Errorf("expected short fields to be kept: %s", got)
+	}
+
+	html := "<html>" + strings.Repeat("x", maxDumpBodyLength) + "</html>"
+	if got := scrubBody([]byte(html)); got != html[:maxDumpBodyLength]+"... (13 bytes truncated)" {
+		t.Errorf("expected a body that is not JSON to be capped at %d bytes, got %d bytes ending in %q", maxDumpBodyLength, len(got), got[len(got)-40:])
+	}
+
+	if got := scrubBody([]byte("Bad Gateway")); got != "Bad Gateway" {
+		t.Errorf("expected a short body that is not JSON to be kept, got %q", got)
+	}
+	if got := scrubBody(nil); got != "" {
+		t.Errorf("expected an empty body to stay empty, got %q", got)
+	}
+}
+
+func TestLogLevelEnabled(t *testing.T) {
+	for _, name := range append(logLevelEnvs, "TF_ACC_LOG_PATH") {
+		t.Setenv(name, "")
+	}
+
+	if logLevelEnabled(hclog.Trace) {
+		t.Errorf("expected TRACE to be disabled without any TF_LOG variable")
+	}
+
+	t.Setenv("TF_LOG", "TRACE")
+	if !logLevelEnabled(hclog.Trace) {
+		t.Errorf("expected TRACE to be enabled by TF_LOG=TRACE")
+	}
+
+	// The most specific variable wins.
+	t.Setenv("TF_LOG_PROVIDER_NYNO_API", "DEBUG")
+	if logLevelEnabled(hclog.Trace) {
+		t.Errorf("expected TRACE to be disabled by TF_LOG_PROVIDER_NYNO_API=DEBUG")
+	}
+	if !logLevelEnabled(hclog.Debug) {
+		t.Errorf("expected DEBUG to be enabled by TF_LOG_PROVIDER_NYNO_API=DEBUG")
+	}
+}
+
+func TestDumpResponse_notLogged(t *testing.T) {
+	for _, name := range append(logLevelEnvs, "TF_ACC_LOG_PATH") {
+		t.Setenv(name, "")
+	}
+
+	c := New("https://nyno.example.com")
+	body := &countingReader{Reader: strings.NewReader(`{"id": "1"}`)}
+	req, _ := http.NewRequest(http.MethodGet, "https://nyno.example.com/roles/1", nil)
+	r := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(body)}
+
+	c.dumpResponse(context.Background(), req, r)
+	if body.n != 0 {
+		t.Fatalf("expected the body to be left unread, %d bytes were read", body.n)
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	if string(data) != `{"id": "1"}` {
+		t.Fatalf("expected the body to be intact, got %q", data)
+	}
+}
+
+type countingReader struct {
+	*strings.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
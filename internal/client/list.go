@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listPageSize is the number of items asked for per page of a list.
+const listPageSize = 100
+
+// listPage is one page of a list of the API. An empty NextCursor marks the
+// last page.
+type listPage struct {
+	Items      json.RawMessage `json:"items"`
+	NextCursor string          `json:"nextCursor"`
+}
+
+// list follows every page of the list at path, handing the items of each
+// page, a JSON array, to add. A cursor coming back a second time would make
+// it loop forever and is reported as an error.
+func (c *Client) list(ctx context.Context, path string, add func(items json.RawMessage) error) error {
+	seen := map[string]bool{}
+	cursor := ""
+	for {
+		query := url.Values{"limit": {strconv.Itoa(listPageSize)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		var page listPage
+		if err := c.do(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &page); err != nil {
+			return err
+		}
+		if len(page.Items) > 0 {
+			if err := add(page.Items); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		if seen[page.NextCursor] {
+			return fmt.Errorf("the list of %s returned the cursor %q twice", path, page.NextCursor)
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
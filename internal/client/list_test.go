@@ -0,0 +1,74 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+func TestList_pages(t *testing.T) {
+	server := fakenyno.NewServer()
+	defer server.Close()
+	server.PageSize = 2
+
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("object-%d", i)
+		server.PutRole(&client.Role{Name: name})
+		server.PutTemplate(&client.Template{Name: name})
+		server.AddRepository(name, "https://github.com/nyno-app/"+name, true)
+		server.PutCredential(&client.Credential{Name: name, Type: "github", Secret: "secret"})
+	}
+
+	c := client.New(server.URL)
+	c.UseAPIToken(fakenyno.DefaultAPIToken)
+	ctx := context.Background()
+
+	roles, err := c.ListRoles(ctx)
+	if err != nil || len(roles) != 5 {
+		t.Errorf("ListRoles: expected 5 roles over 3 pages, got %d, %v", len(roles), err)
+	}
+	templates, err := c.ListTemplates(ctx)
+	if err != nil || len(templates) != 5 {
+		t.Errorf("ListTemplates: expected 5 templates over 3 pages, got %d, %v", len(templates), err)
+	}
+	repositories, err := c.ListRepositories(ctx)
+	if err != nil || len(repositories) != 5 {
+		t.Errorf("ListRepositories: expected 5 repositories over 3 pages, got %d, %v", len(repositories), err)
+	}
+	credentials, err := c.ListCredentials(ctx)
+	if err != nil || len(credentials) != 5 {
+		t.Errorf("ListCredentials: expected 5 credentials over 3 pages, got %d, %v", len(credentials), err)
+	}
+
+	seen := map[string]bool{}
+	for _, role := range roles {
+		if seen[role.ID] {
+			t.Errorf("ListRoles: role %s listed twice", role.ID)
+		}
+		seen[role.ID] = true
+	}
+}
+
+func TestList_cursorCycle(t *testing.T) {
+	// The cursors go a, b, a, ... forever.
+	next := map[string]string{"": "a", "a": "b", "b": "a"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		fmt.Fprintf(w, `{"items": [{"id": %q, "name": "role"}], "nextCursor": %q}`, cursor+"-role", next[cursor])
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL)
+	c.UseAPIToken("api-token")
+
+	_, err := c.ListRoles(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `returned the cursor "a" twice`) {
+		t.Fatalf("expected the repeated cursor to be reported, got %v", err)
+	}
+}
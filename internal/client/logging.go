@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// logSubsystem is the tflog subsystem of the client. Its level can be set on
+// its own with TF_LOG_PROVIDER_NYNO_API.
+const logSubsystem = "nyno_api"
+
+const redacted = "***"
+
+// userinfoPattern matches the credentials embedded in a URL, such as those of
+// a Git repository URL.
+var userinfoPattern = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// redactor masks every secret the client knows of in log output.
+type redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+func (r *redactor) add(secret string) {
+	if secret == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.secrets {
+		if s == secret {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, secret)
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+
+	return userinfoPattern.ReplaceAllString(s, "${1}"+redacted+"@")
+}
+
+// logLevelEnvs are the variables setting the level of the client subsystem,
+// from the most specific one, which wins, to the least.
+var logLevelEnvs = []string{"TF_LOG_PROVIDER_NYNO_API", "TF_LOG_PROVIDER_NYNO", "TF_LOG_PROVIDER", "TF_LOG"}
+
+// logLevelEnabled reports whether the client subsystem logs at level, for
+// work only worth doing when its output is logged.
+func logLevelEnabled(level hclog.Level) bool {
+	for _, name := range logLevelEnvs {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		// JSON logs everything, like TRACE.
+		enabled := hclog.LevelFromString(value)
+		if strings.EqualFold(value, "json") {
+			enabled = hclog.Trace
+		}
+		return enabled != hclog.NoLevel && enabled <= level
+	}
+
+	// The acceptance test framework logs at TRACE to TF_ACC_LOG_PATH.
+	return os.Getenv("TF_ACC_LOG_PATH") != ""
+}
+
+// logContext returns ctx with the logger of the client subsystem, carrying the
+// fields Terraform set on the provider logger such as tf_resource_type.
+func logContext(ctx context.Context) context.Context {
+	return tflog.NewSubsystem(ctx, logSubsystem, tflog.WithRootFields(), tflog.WithLevelFromEnv("TF_LOG_PROVIDER_NYNO", "api"))
+}
+
+func (c *Client) logTrace(ctx context.Context, msg string, fields map[string]interface{}) {
+	tflog.SubsystemTrace(ctx, logSubsystem, c.redactor.redact(msg), c.redactFields(fields))
+}
+
+func (c *Client) logDebug(ctx context.Context, msg string, fields map[string]interface{}) {
+	tflog.SubsystemDebug(ctx, logSubsystem, c.redactor.redact(msg), c.redactFields(fields))
+}
+
+func (c *Client) logInfo(ctx context.Context, msg string, fields map[string]interface{}) {
+	tflog.SubsystemInfo(ctx, logSubsystem, c.redactor.redact(msg), c.redactFields(fields))
+}
+
+func (c *Client) logWarn(ctx context.Context, msg string, fields map[string]interface{}) {
+	tflog.SubsystemWarn(ctx, logSubsystem, c.redactor.redact(msg), c.redactFields(fields))
+}
+
+func (c *Client) redactFields(fields map[string]interface{}) map[string]interface{} {
+	redactedFields := make(map[string]interface{}, len(fields))
+
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			redactedFields[key] = c.redactor.redact(v)
+		case error:
+			redactedFields[key] = c.redactor.redact(v.Error())
+		default:
+			redactedFields[key] = value
+		}
+	}
+
+	return redactedFields
+}
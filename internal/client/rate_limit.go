@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// rateLimiter is a token bucket shared by every request of a client: it holds
+// up to burst tokens, refilled at rate tokens per second, and each request
+// takes one.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// SetRateLimit limits the client to requestsPerSecond requests per second on
+// average, allowing bursts of up to burst requests. A rate of zero removes the
+// limit.
+func (c *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond <= 0 {
+		c.rateLimiter = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	c.rateLimiter = &rateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystem, "Nyno API rate limit reached, waiting", map[string]interface{}{
+		"wait": delay.String(),
+	})
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token, possibly ahead of its refill, and returns how long
+// the caller has to wait before it is available.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back the token of a reservation that was not used.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+}
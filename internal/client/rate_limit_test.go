@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	c := New("https://nyno.example.com")
+	c.SetRateLimit(10, 2)
+	l := c.rateLimiter
+
+	// The burst is available right away, then a token every 100ms.
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(); delay != 0 {
+			t.Fatalf("request %d of the burst: expected no wait, got %s", i+1, delay)
+		}
+	}
+	if delay := l.reserve(); delay < 90*time.Millisecond || delay > 100*time.Millisecond {
+		t.Fatalf("expected a wait of about 100ms after the burst, got %s", delay)
+	}
+	if delay := l.reserve(); delay < 190*time.Millisecond || delay > 200*time.Millisecond {
+		t.Fatalf("expected a wait of about 200ms for the next request, got %s", delay)
+	}
+
+	// Idle time refills the bucket, never beyond the burst.
+	l.mu.Lock()
+	l.last = l.last.Add(-time.Hour)
+	l.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(); delay != 0 {
+			t.Fatalf("request %d after idling: expected no wait, got %s", i+1, delay)
+		}
+	}
+	if delay := l.reserve(); delay <= 0 {
+		t.Fatalf("expected the refill to stop at the burst")
+	}
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	c := New("https://nyno.example.com")
+	c.SetRateLimit(20, 1)
+	l := c.rateLimiter
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected 3 requests at 20/s with a burst of 1 to take about 100ms, took %s", elapsed)
+	}
+
+	// A canceled wait gives its token back.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.5 {
+		t.Fatalf("expected the canceled reservation to be given back, %f tokens left", tokens)
+	}
+}
+
+func TestSetRateLimit_disabled(t *testing.T) {
+	c := New("https://nyno.example.com")
+	c.SetRateLimit(0, 10)
+	if c.rateLimiter != nil {
+		t.Fatalf("expected a rate of 0 to remove the limit")
+	}
+	if err := c.rateLimiter.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
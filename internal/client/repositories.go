@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type Repository struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	IsActive bool   `json:"isActive"`
+}
+
+// CreateRepository connects a Git repository to the organization.
+func (c *Client) CreateRepository(ctx context.Context, repository *Repository) (*Repository, error) {
+	var response Repository
+	if err := c.do(ctx, http.MethodPost, "/repositories", repository, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetRepository(ctx context.Context, id string) (*Repository, error) {
+	var response Repository
+	if err := c.do(ctx, http.MethodGet, "/repositories/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListRepositories returns every repository connected to the organization.
+func (c *Client) ListRepositories(ctx context.Context) ([]*Repository, error) {
+	var repositories []*Repository
+	err := c.list(ctx, "/repositories", func(items json.RawMessage) error {
+		var page []*Repository
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		repositories = append(repositories, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repositories, nil
+}
+
+func (c *Client) UpdateRepository(ctx context.Context, repository *Repository) (*Repository, error) {
+	var response Repository
+	if err := c.do(ctx, http.MethodPut, "/repositories/"+url.PathEscape(repository.ID), repository, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteRepository disconnects a repository from the organization.
+func (c *Client) DeleteRepository(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/repositories/"+url.PathEscape(id), nil, nil)
+}
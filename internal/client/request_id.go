@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// serverRequestIDHeaders are the headers the API or a gateway in front of it
+// may use to return its own request ID.
+var serverRequestIDHeaders = []string{requestIDHeader, "X-Correlation-ID"}
+
+type requestIDKey struct{}
+
+// withRequestID returns ctx carrying a new request ID, sent in the
+// X-Request-ID header of every attempt of a call and added to its logs.
+func withRequestID(ctx context.Context) context.Context {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return ctx
+	}
+
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return tflog.SubsystemWith(ctx, logSubsystem, "request_id", id)
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
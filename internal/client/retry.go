@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryMaxWait = 30 * time.Second
+
+	retryBaseWait = time.Second
+)
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetRetryPolicy sets how many times a failed request is retried and the
+// longest wait between two attempts. A maxWait that is not positive falls back
+// to DefaultRetryMaxWait.
+func (c *Client) SetRetryPolicy(maxRetries int, maxWait time.Duration) {
+	if maxWait <= 0 {
+		maxWait = DefaultRetryMaxWait
+	}
+	c.maxRetries = maxRetries
+	c.retryMaxWait = maxWait
+}
+
+// sendWithRetry performs the request returned by build, building it again for
+// every attempt so that its body can be replayed. Connection errors are only
+// retried for idempotent requests, since a POST may have been processed before
+// the connection dropped.
+func (c *Client) sendWithRetry(ctx context.Context, idempotent bool, build func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+
+		c.dumpRequest(ctx, req)
+
+		start := time.Now()
+		r, err := c.httpClient.Do(req)
+		c.logResponse(ctx, req, r, err, attempt, time.Since(start))
+		if err == nil {
+			c.dumpResponse(ctx, req, r)
+		}
+
+		if ctx.Err() != nil {
+			discardBody(r)
+			return nil, ctx.Err()
+		}
+		if attempt > c.maxRetries || !shouldRetry(idempotent, r, err) {
+			return r, err
+		}
+
+		wait := c.retryWait(attempt, r)
+
+		discardBody(r)
+		c.logWarn(ctx, "Retrying Nyno API request", map[string]interface{}{
+			"method":      req.Method,
+			"path":        req.URL.Path,
+			"attempt":     attempt,
+			"max_retries": c.maxRetries,
+			"wait":        wait.String(),
+		})
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// discardBody drains and closes the body of r, if any, so that its connection
+// can be reused.
+func discardBody(r *http.Response) {
+	if r != nil {
+		io.Copy(ioutil.Discard, r.Body)
+		r.Body.Close()
+	}
+}
+
+// logResponse logs the outcome of a single attempt.
+func (c *Client) logResponse(ctx context.Context, req *http.Request, r *http.Response, err error, attempt int, duration time.Duration) {
+	fields := map[string]interface{}{
+		"method":      req.Method,
+		"path":        req.URL.Path,
+		"attempt":     attempt,
+		"duration_ms": duration.Milliseconds(),
+	}
+
+	if err != nil {
+		fields["error"] = err
+		c.logDebug(ctx, "Nyno API request failed", fields)
+		return
+	}
+
+	fields["status"] = r.StatusCode
+	c.logDebug(ctx, "Nyno API request", fields)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether an attempt is worth repeating. 429 and gateway
+// errors mean the API did not process the request and are retried for every
+// method, other server errors only for idempotent requests.
+func shouldRetry(idempotent bool, r *http.Response, err error) bool {
+	if err != nil {
+		return idempotent
+	}
+
+	switch r.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return idempotent && r.StatusCode >= 500
+}
+
+// retryWait returns the wait before the next attempt: the Retry-After of the
+// response when there is one, otherwise an exponential backoff with jitter.
+// Both are capped to the retry max wait.
+func (c *Client) retryWait(attempt int, r *http.Response) time.Duration {
+	if r != nil {
+		if wait, ok := parseRetryAfter(r.Header.Get("Retry-After")); ok {
+			return minDuration(wait, c.retryMaxWait)
+		}
+	}
+
+	backoff := retryBaseWait << uint(attempt-1)
+	if backoff <= 0 || backoff > c.retryMaxWait {
+		backoff = c.retryMaxWait
+	}
+
+	jitterMu.Lock()
+	jitter := time.Duration(jitterRand.Int63n(int64(backoff)/2 + 1))
+	jitterMu.Unlock()
+
+	return backoff/2 + jitter
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of the API served by handler, retrying up to
+// maxRetries times without waiting more than 10ms between attempts.
+func newTestClient(t *testing.T, maxRetries int, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := New(server.URL)
+	c.UseAPIToken("api-token")
+	c.SetRetryPolicy(maxRetries, 10*time.Millisecond)
+	return c
+}
+
+func TestSendWithRetry_statusCodes(t *testing.T) {
+	for _, tc := range []struct {
+		method   string
+		status   int
+		attempts int32
+	}{
+		// The API did not process the request, every method is retried.
+		{http.MethodPost, http.StatusServiceUnavailable, 3},
+		{http.MethodPost, http.StatusTooManyRequests, 3},
+		{http.MethodGet, http.StatusBadGateway, 3},
+		// The request may have been processed, only idempotent ones are.
+		{http.MethodGet, http.StatusInternalServerError, 3},
+		{http.MethodPut, http.StatusInternalServerError, 3},
+		{http.MethodPost, http.StatusInternalServerError, 1},
+		// Client errors are never retried.
+		{http.MethodGet, http.StatusNotFound, 1},
+	} {
+		var attempts int32
+		c := newTestClient(t, 2, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(tc.status)
+		})
+
+		err := c.do(context.Background(), tc.method, "/roles", nil, nil)
+		if respErr, ok := err.(*ResponseError); !ok || respErr.StatusCode != tc.status {
+			t.Errorf("%s answered %d: expected a ResponseError with that status, got %v", tc.method, tc.status, err)
+		}
+		if attempts := atomic.LoadInt32(&attempts); attempts != tc.attempts {
+			t.Errorf("%s answered %d: expected %d attempts, got %d", tc.method, tc.status, tc.attempts, attempts)
+		}
+	}
+}
+
+func TestSendWithRetry_recovers(t *testing.T) {
+	var attempts int32
+	c := newTestClient(t, 3, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id": "1", "name": "developers"}`))
+	})
+
+	var role Role
+	if err := c.do(context.Background(), http.MethodPost, "/roles", &Role{Name: "developers"}, &role); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %s", err)
+	}
+	if attempts := atomic.LoadInt32(&attempts); attempts != 3 || role.ID != "1" {
+		t.Fatalf("expected role 1 after 3 attempts, got %q after %d", role.ID, attempts)
+	}
+}
+
+func TestSendWithRetry_connectionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		method   string
+		attempts int32
+	}{
+		{http.MethodGet, 3},
+		// A POST may have been processed before the connection dropped.
+		{http.MethodPost, 1},
+	} {
+		var attempts int32
+		c := newTestClient(t, 2, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		})
+
+		if err := c.do(context.Background(), tc.method, "/roles", &Role{Name: "developers"}, nil); err == nil {
+			t.Errorf("%s: expected a connection error", tc.method)
+		}
+		if attempts := atomic.LoadInt32(&attempts); attempts != tc.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", tc.method, tc.attempts, attempts)
+		}
+	}
+}
+
+// closeRecorder is a response body that records being closed.
+type closeRecorder struct {
+	io.Reader
+	closed int32
+}
+
+func (b *closeRecorder) Close() error {
+	atomic.StoreInt32(&b.closed, 1)
+	return nil
+}
+
+// roundTripFunc serves requests without a server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSendWithRetry_canceledClosesBody(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := &closeRecorder{Reader: strings.NewReader(`{}`)}
+	c := New("https://nyno.example.com")
+	c.UseAPIToken("api-token")
+	c.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// The context ends while the response is on its way.
+		cancel()
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body, Request: req}, nil
+	})
+
+	if err := c.do(ctx, http.MethodGet, "/roles", nil, nil); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if atomic.LoadInt32(&body.closed) != 1 {
+		t.Fatal("expected the body of the response to be closed")
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	c := New("https://nyno.example.com")
+	c.SetRetryPolicy(DefaultMaxRetries, 10*time.Second)
+
+	for attempt, backoff := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 64: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if wait := c.retryWait(attempt, nil); wait < backoff/2 || wait > backoff {
+				t.Errorf("attempt %d: expected a wait between %s and %s, got %s", attempt, backoff/2, backoff, wait)
+			}
+		}
+	}
+
+	r := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	if wait := c.retryWait(1, r); wait != 3*time.Second {
+		t.Errorf("expected the Retry-After of 3s, got %s", wait)
+	}
+
+	r.Header.Set("Retry-After", "3600")
+	if wait := c.retryWait(1, r); wait != 10*time.Second {
+		t.Errorf("expected the Retry-After to be capped to 10s, got %s", wait)
+	}
+}
+
+func TestRetryWait_nonPositiveMaxWait(t *testing.T) {
+	c := New("https://nyno.example.com")
+
+	for _, maxWait := range []time.Duration{0, -10 * time.Second} {
+		c.SetRetryPolicy(DefaultMaxRetries, maxWait)
+		if wait := c.retryWait(1, nil); wait < retryBaseWait/2 || wait > retryBaseWait {
+			t.Errorf("max wait %s: expected a wait between %s and %s, got %s", maxWait, retryBaseWait/2, retryBaseWait, wait)
+		}
+		if wait := c.retryWait(64, nil); wait < DefaultRetryMaxWait/2 || wait > DefaultRetryMaxWait {
+			t.Errorf("max wait %s: expected the default max wait to apply, got %s", maxWait, wait)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if wait, ok := parseRetryAfter("120"); !ok || wait != 120*time.Second {
+		t.Errorf("seconds: expected 2m0s, got %s, %v", wait, ok)
+	}
+
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	if wait, ok := parseRetryAfter(date); !ok || wait < 28*time.Second || wait > 30*time.Second {
+		t.Errorf("HTTP date: expected about 30s, got %s, %v", wait, ok)
+	}
+
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	if wait, ok := parseRetryAfter(past); !ok || wait != 0 {
+		t.Errorf("past HTTP date: expected 0s, got %s, %v", wait, ok)
+	}
+
+	for _, value := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(value); ok {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type Role struct {
+	ID string `json:"id,omitempty"`
+
+	Name                          string `json:"name"`
+	CreateCredentials             bool   `json:"createCredentials"`
+	GetCredentials                bool   `json:"getCredentials"`
+	UpdateCredentials             bool   `json:"updateCredentials"`
+	DeleteCredentials             bool   `json:"deleteCredentials"`
+	CreateRepository              bool   `json:"createRepository"`
+	GetRepository                 bool   `json:"getRepository"`
+	UpdateRepository              bool   `json:"updateRepository"`
+	DeleteRepository              bool   `json:"deleteRepository"`
+	GetUser                       bool   `json:"getUser"`
+	UpdateUser                    bool   `json:"updateUser"`
+	CreateUser                    bool   `json:"createUser"`
+	GetRole                       bool   `json:"getRole"`
+	UpdateRole                    bool   `json:"updateRole"`
+	CreateRole                    bool   `json:"createRole"`
+	DeleteRole                    bool   `json:"deleteRole"`
+	GetAllTemplates               bool   `json:"getAllTemplates"`
+	UpdateAllTemplates            bool   `json:"updateAllTemplates"`
+	CreateTemplate                bool   `json:"createTemplate"`
+	DeleteAllTemplates            bool   `json:"deleteAllTemplates"`
+	GetAllDeployments             bool   `json:"getAllDeployments"`
+	UpdateAllDeployments          bool   `json:"updateAllDeployments"`
+	CreateDeploymentsAllTemplates bool   `json:"createDeploymentsAllTemplates"`
+	DeleteAllDeployment           bool   `json:"deleteAllDeployment"`
+	GetGlobalSettings             bool   `json:"getGlobalSettings"`
+	UpdateGlobalSettings          bool   `json:"updateGlobalSettings"`
+}
+
+func (c *Client) CreateRole(ctx context.Context, role *Role) (*Role, error) {
+	var response Role
+	if err := c.do(ctx, http.MethodPost, "/roles", role, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetRole(ctx context.Context, id string) (*Role, error) {
+	var response Role
+	if err := c.do(ctx, http.MethodGet, "/roles/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListRoles returns every role of the organization.
+func (c *Client) ListRoles(ctx context.Context) ([]*Role, error) {
+	var roles []*Role
+	err := c.list(ctx, "/roles", func(items json.RawMessage) error {
+		var page []*Role
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		roles = append(roles, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (c *Client) UpdateRole(ctx context.Context, role *Role) (*Role, error) {
+	var response Role
+	if err := c.do(ctx, http.MethodPut, "/roles/"+url.PathEscape(role.ID), role, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) DeleteRole(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/roles/"+url.PathEscape(id), nil, nil)
+}
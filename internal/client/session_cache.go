@@ -0,0 +1,201 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// defaultSessionLifetime is assumed for sessions whose login response does not
+// tell when they expire. A session revoked earlier is dropped on its first 401.
+const defaultSessionLifetime = 12 * time.Hour
+
+// SessionCache keeps session tokens in a file readable only by its owner, so
+// that consecutive Terraform runs reuse a session instead of logging in again.
+type SessionCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+// cachedSession is an entry of the cache. Verifier proves which password
+// the session was opened with, so that a changed or mistyped password is
+// never covered up by a session cached with the previous one.
+type cachedSession struct {
+	Token    string    `json:"token"`
+	Expires  time.Time `json:"expires"`
+	Salt     string    `json:"salt"`
+	Verifier string    `json:"verifier"`
+}
+
+// NewSessionCache returns a cache stored at path. The file and its directory
+// are created on the first write.
+func NewSessionCache(path string) *SessionCache {
+	return &SessionCache{path: path}
+}
+
+// sessionKey identifies the sessions of one user of one organization on one
+// Nyno instance without writing any of them in clear to the cache file.
+func sessionKey(endpoint string, organization string, username string) string {
+	sum := sha256.Sum256([]byte(endpoint + "\x00" + organization + "\x00" + username))
+	return hex.EncodeToString(sum[:])
+}
+
+// Cost parameters of the scrypt password verifier, the ones recommended for
+// interactive logins: about 100ms and 32MB per computation.
+const (
+	verifierN      = 1 << 15
+	verifierR      = 8
+	verifierP      = 1
+	verifierLength = 32
+)
+
+// passwordVerifier derives a verifier of password with scrypt. The salt is
+// stored next to the verifier, so anyone who can read the cache file can still
+// test guesses of the password against it; the memory-hard derivation only
+// makes each guess as expensive as it can reasonably be.
+func passwordVerifier(salt string, password string) (string, error) {
+	key, err := scrypt.Key([]byte(password), []byte(salt), verifierN, verifierR, verifierP, verifierLength)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func newSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+func (s *SessionCache) get(key string, password string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return "", false
+	}
+
+	session, ok := sessions[key]
+	if !ok || time.Now().After(session.Expires) {
+		return "", false
+	}
+	if session.Verifier == "" || session.Salt == "" {
+		return "", false
+	}
+	verifier, err := passwordVerifier(session.Salt, password)
+	if err != nil || subtle.ConstantTimeCompare([]byte(session.Verifier), []byte(verifier)) != 1 {
+		return "", false
+	}
+
+	return session.Token, true
+}
+
+func (s *SessionCache) put(key string, password string, token string, expires time.Time) error {
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	verifier, err := passwordVerifier(salt, password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		sessions = map[string]cachedSession{}
+	}
+
+	now := time.Now()
+	for k, session := range sessions {
+		if now.After(session.Expires) {
+			delete(sessions, k)
+		}
+	}
+	sessions[key] = cachedSession{
+		Token:    token,
+		Expires:  expires,
+		Salt:     salt,
+		Verifier: verifier,
+	}
+
+	return s.write(sessions)
+}
+
+func (s *SessionCache) remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return nil
+	}
+	if _, ok := sessions[key]; !ok {
+		return nil
+	}
+
+	delete(sessions, key)
+	return s.write(sessions)
+}
+
+func (s *SessionCache) read() (map[string]cachedSession, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions map[string]cachedSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// write replaces the cache file atomically so that a concurrent Terraform run
+// never reads a partially written file.
+func (s *SessionCache) write(sessions map[string]cachedSession) error {
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".sessions-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
@@ -0,0 +1,56 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+func TestLogin_sessionCache(t *testing.T) {
+	server := fakenyno.NewServer()
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	cache := client.NewSessionCache(path)
+	login := func(password string) error {
+		c := client.New(server.URL)
+		c.UseSessionCache(cache)
+		return c.Login(context.Background(), fakenyno.DefaultUsername, password, fakenyno.DefaultOrganization)
+	}
+
+	if err := login(fakenyno.DefaultPassword); err != nil {
+		t.Fatalf("first login: %s", err)
+	}
+	if err := login(fakenyno.DefaultPassword); err != nil {
+		t.Fatalf("second login: %s", err)
+	}
+	if n := server.Logins(); n != 1 {
+		t.Fatalf("expected the cached session to be reused, got %d logins", n)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || strings.Contains(string(data), fakenyno.DefaultPassword) {
+		t.Fatalf("expected a cache file without the password, got %q, %v", data, err)
+	}
+
+	// A wrong password is never covered up by the cached session.
+	if err := login("wrong-password"); !errors.Is(err, client.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+
+	// Neither is a password changed since the session was cached.
+	server.Password = "new-password"
+	if err := login("new-password"); err != nil {
+		t.Fatalf("login with the new password: %s", err)
+	}
+	if n := server.Logins(); n != 2 {
+		t.Fatalf("expected a login with the new password, got %d logins", n)
+	}
+	if err := login(fakenyno.DefaultPassword); !errors.Is(err, client.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials for the old password, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type Action struct {
+	ID           string `json:"id,omitempty"`
+	Type         string `json:"type"`
+	Path         string `json:"path"`
+	SourceBranch string `json:"sourceBranch"`
+	TargetBranch string `json:"targetBranch"`
+	TemplateCode string `json:"templateCode"`
+	PullRequest  bool   `json:"pullRequest"`
+	RepositoryId string `json:"repositoryId"`
+}
+
+type Variable struct {
+	ID           string `json:"id,omitempty"`
+	Title        string `json:"title"`
+	Variable     string `json:"variable"`
+	Description  string `json:"description"`
+	Type         string `json:"type"`
+	DefaultValue string `json:"defaultValue"`
+}
+
+type Permissions struct {
+	ID          string `json:"id,omitempty"`
+	AccessLevel string `json:"accessLevel"`
+	RoleId      string `json:"roleId"`
+}
+
+type Template struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Actions     []*Action      `json:"actions"`
+	Variables   []*Variable    `json:"variables"`
+	Permissions []*Permissions `json:"permissions"`
+}
+
+func (c *Client) CreateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	var response Template
+	if err := c.do(ctx, http.MethodPost, "/templates", template, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetTemplate(ctx context.Context, id string) (*Template, error) {
+	var response Template
+	if err := c.do(ctx, http.MethodGet, "/templates/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListTemplates returns every template of the organization.
+func (c *Client) ListTemplates(ctx context.Context) ([]*Template, error) {
+	var templates []*Template
+	err := c.list(ctx, "/templates", func(items json.RawMessage) error {
+		var page []*Template
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		templates = append(templates, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (c *Client) UpdateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	var response Template
+	if err := c.do(ctx, http.MethodPut, "/templates/"+url.PathEscape(template.ID), template, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) DeleteTemplate(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/templates/"+url.PathEscape(id), nil, nil)
+}
@@ -0,0 +1,841 @@
+// Package fakenyno implements an in-memory stand-in for the Nyno API, so that
+// the provider can be exercised offline, e.g. by acceptance tests.
+package fakenyno
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
+
+const (
+	DefaultUsername     = "terraform"
+	DefaultPassword     = "terraform-password"
+	DefaultOrganization = "terraform"
+	DefaultAPIToken     = "terraform-api-token"
+	DefaultPageSize     = 50
+
+	sessionCookieName = "next-auth.session-token"
+)
+
+// Server is a fake Nyno API listening on a local address. Its zero value is
+// not usable, use NewServer.
+type Server struct {
+	*httptest.Server
+
+	Username     string
+	Password     string
+	Organization string
+	APIToken     string
+
+	// CheckCredential decides whether a credential works against its Git
+	// provider, by returning an error explaining why not. Every credential
+	// works if it is nil.
+	CheckCredential func(credential *client.Credential) error
+
+	// Latency delays every authenticated request before it is checked, so
+	// that parallel requests overlap.
+	Latency time.Duration
+
+	// PageSize caps the number of items in a page of a list, whatever the
+	// limit asked for.
+	PageSize int
+
+	mu           sync.Mutex
+	sessions     map[string]bool
+	logins       int
+	expireAfter  int
+	roles        map[string]*client.Role
+	templates    map[string]*client.Template
+	repositories map[string]*client.Repository
+	credentials  map[string]*client.Credential
+	failures     []*failure
+}
+
+// failure is an error response returned instead of handling the next request
+// matching method and path.
+type failure struct {
+	method  string
+	path    string
+	status  int
+	message string
+}
+
+// NewServer starts a fake API accepting the Default* credentials. It must be
+// closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		Username:     DefaultUsername,
+		Password:     DefaultPassword,
+		Organization: DefaultOrganization,
+		APIToken:     DefaultAPIToken,
+		PageSize:     DefaultPageSize,
+		sessions:     map[string]bool{},
+		roles:        map[string]*client.Role{},
+		templates:    map[string]*client.Template{},
+		repositories: map[string]*client.Repository{},
+		credentials:  map[string]*client.Credential{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/credentials", s.handleLogin)
+	mux.HandleFunc("/roles", s.authenticated(s.handleRoles))
+	mux.HandleFunc("/roles/", s.authenticated(s.handleRole))
+	mux.HandleFunc("/templates", s.authenticated(s.handleTemplates))
+	mux.HandleFunc("/templates/", s.authenticated(s.handleTemplate))
+	mux.HandleFunc("/repositories", s.authenticated(s.handleRepositories))
+	mux.HandleFunc("/repositories/", s.authenticated(s.handleRepository))
+	mux.HandleFunc("/credentials", s.authenticated(s.handleCredentials))
+	mux.HandleFunc("/credentials/", s.authenticated(s.handleCredential))
+
+	s.Server = httptest.NewServer(s.withFailures(mux))
+	return s
+}
+
+// FailNext makes the next request to method and path answer status with
+// message in the API's error format.
+func (s *Server) FailNext(method string, path string, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{method: method, path: path, status: status, message: message})
+}
+
+// ExpireSessionsAfter invalidates every session when the nth next request
+// authenticated by a session arrives, which then fails, as if the sessions
+// timed out in the middle of a run.
+func (s *Server) ExpireSessionsAfter(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireAfter = n
+}
+
+// Logins returns the number of successful logins so far.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logins
+}
+
+// ExpireSessions invalidates every session, as if they all timed out.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]bool{}
+}
+
+// AddRepository connects a repository to the organization.
+func (s *Server) AddRepository(name string, repositoryURL string, isActive bool) *client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repository := &client.Repository{ID: newID(), Name: name, Url: repositoryURL, IsActive: isActive}
+	s.repositories[repository.ID] = repository
+
+	copied := *repository
+	return &copied
+}
+
+// Repository returns a copy of the repository with id, or nil.
+func (s *Server) Repository(id string) *client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repository, ok := s.repositories[id]
+	if !ok {
+		return nil
+	}
+
+	copied := *repository
+	return &copied
+}
+
+// Repositories returns a copy of every repository.
+func (s *Server) Repositories() []*client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repositories := make([]*client.Repository, 0, len(s.repositories))
+	for _, repository := range s.repositories {
+		copied := *repository
+		repositories = append(repositories, &copied)
+	}
+
+	return repositories
+}
+
+// PutRepository creates or replaces a repository, as an edit made in the Nyno
+// UI would.
+func (s *Server) PutRepository(repository *client.Repository) *client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *repository
+	if stored.ID == "" {
+		stored.ID = newID()
+	}
+	s.repositories[stored.ID] = &stored
+
+	copied := stored
+	return &copied
+}
+
+// DeleteRepository disconnects a repository, as a deletion made in the Nyno
+// UI would.
+func (s *Server) DeleteRepository(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.repositories, id)
+}
+
+// Credential returns a copy of the credential with id, secret included, or
+// nil.
+func (s *Server) Credential(id string) *client.Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credential, ok := s.credentials[id]
+	if !ok {
+		return nil
+	}
+
+	copied := *credential
+	return &copied
+}
+
+// Credentials returns a copy of every credential, secrets included.
+func (s *Server) Credentials() []*client.Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials := make([]*client.Credential, 0, len(s.credentials))
+	for _, credential := range s.credentials {
+		copied := *credential
+		credentials = append(credentials, &copied)
+	}
+
+	return credentials
+}
+
+// PutCredential creates or replaces a credential, as an edit made in the Nyno
+// UI would.
+func (s *Server) PutCredential(credential *client.Credential) *client.Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *credential
+	if stored.ID == "" {
+		stored.ID = newID()
+	}
+	s.credentials[stored.ID] = &stored
+
+	copied := stored
+	return &copied
+}
+
+// DeleteCredential removes a credential, as a deletion made in the Nyno UI
+// would.
+func (s *Server) DeleteCredential(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.credentials, id)
+}
+
+// Role returns a copy of the role with id, or nil.
+func (s *Server) Role(id string) *client.Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	role, ok := s.roles[id]
+	if !ok {
+		return nil
+	}
+
+	copied := *role
+	return &copied
+}
+
+// Roles returns a copy of every role.
+func (s *Server) Roles() []*client.Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := make([]*client.Role, 0, len(s.roles))
+	for _, role := range s.roles {
+		copied := *role
+		roles = append(roles, &copied)
+	}
+
+	return roles
+}
+
+// PutRole creates or replaces a role, as an edit made in the Nyno UI would.
+func (s *Server) PutRole(role *client.Role) *client.Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *role
+	if stored.ID == "" {
+		stored.ID = newID()
+	}
+	s.roles[stored.ID] = &stored
+
+	copied := stored
+	return &copied
+}
+
+// DeleteRole removes a role, as a deletion made in the Nyno UI would.
+func (s *Server) DeleteRole(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.roles, id)
+}
+
+// Template returns a copy of the template with id, or nil.
+func (s *Server) Template(id string) *client.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	template, ok := s.templates[id]
+	if !ok {
+		return nil
+	}
+
+	return copyTemplate(template)
+}
+
+// Templates returns a copy of every template.
+func (s *Server) Templates() []*client.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	templates := make([]*client.Template, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, copyTemplate(template))
+	}
+
+	return templates
+}
+
+// PutTemplate creates or replaces a template, as an edit made in the Nyno UI
+// would. Nested items without an ID get one.
+func (s *Server) PutTemplate(template *client.Template) *client.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := copyTemplate(template)
+	if stored.ID == "" {
+		stored.ID = newID()
+	}
+	assignNestedIDs(stored, s.templates[stored.ID])
+	s.templates[stored.ID] = stored
+
+	return copyTemplate(stored)
+}
+
+// DeleteTemplate removes a template, as a deletion made in the Nyno UI would.
+func (s *Server) DeleteTemplate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.templates, id)
+}
+
+func (s *Server) withFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		for i, f := range s.failures {
+			if f.method == r.Method && f.path == r.URL.Path {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+				s.mu.Unlock()
+				writeError(w, f.status, f.message)
+				return
+			}
+		}
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticated answers 401 to requests without a live session or API token.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(s.Latency)
+
+		if !s.isAuthenticated(r) {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) isAuthenticated(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.APIToken != "" && r.Header.Get("Authorization") == "Bearer "+s.APIToken {
+		return true
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return false
+	}
+
+	if s.expireAfter > 0 {
+		s.expireAfter--
+		if s.expireAfter == 0 {
+			s.sessions = map[string]bool{}
+		}
+	}
+
+	return s.sessions[cookie.Value]
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var payload struct {
+		Username     string `json:"username"`
+		Password     string `json:"password"`
+		Organization string `json:"organization"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if payload.Organization != s.Organization {
+		writeError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if payload.Username != s.Username || payload.Password != s.Password {
+		writeError(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	token := newID()
+	s.sessions[token] = true
+	s.logins++
+
+	writeJSON(w, map[string]string{"sessionToken": token})
+}
+
+func (s *Server) handleRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		items := map[string]interface{}{}
+		for _, role := range s.Roles() {
+			items[role.ID] = role
+		}
+		s.writePage(w, r, items)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var role client.Role
+	if !decode(w, r, &role) {
+		return
+	}
+	if role.Name == "" {
+		writeError(w, http.StatusBadRequest, "Role name is required")
+		return
+	}
+
+	role.ID = ""
+	writeJSON(w, s.PutRole(&role))
+}
+
+func (s *Server) handleRole(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/roles/")
+
+	existing := s.Role(id)
+	if existing == nil {
+		writeError(w, http.StatusNotFound, "Role not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, existing)
+	case http.MethodPut:
+		var role client.Role
+		if !decode(w, r, &role) {
+			return
+		}
+		role.ID = id
+		writeJSON(w, s.PutRole(&role))
+	case http.MethodDelete:
+		s.DeleteRole(id)
+		writeJSON(w, existing)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		items := map[string]interface{}{}
+		for _, template := range s.Templates() {
+			items[template.ID] = template
+		}
+		s.writePage(w, r, items)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var template client.Template
+	if !decode(w, r, &template) {
+		return
+	}
+	if template.Name == "" {
+		writeError(w, http.StatusBadRequest, "Template name is required")
+		return
+	}
+
+	template.ID = ""
+	writeJSON(w, s.PutTemplate(&template))
+}
+
+func (s *Server) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/templates/")
+
+	existing := s.Template(id)
+	if existing == nil {
+		writeError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, existing)
+	case http.MethodPut:
+		var template client.Template
+		if !decode(w, r, &template) {
+			return
+		}
+		template.ID = id
+		writeJSON(w, s.PutTemplate(&template))
+	case http.MethodDelete:
+		s.DeleteTemplate(id)
+		writeJSON(w, existing)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) handleRepositories(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		items := map[string]interface{}{}
+		for _, repository := range s.Repositories() {
+			items[repository.ID] = repository
+		}
+		s.writePage(w, r, items)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var repository client.Repository
+	if !decode(w, r, &repository) {
+		return
+	}
+	if repository.Name == "" || repository.Url == "" {
+		writeError(w, http.StatusBadRequest, "Repository name and url are required")
+		return
+	}
+	if s.repositoryByURL(repository.Url) != nil {
+		writeError(w, http.StatusConflict, "Repository already connected")
+		return
+	}
+
+	repository.ID = ""
+	writeJSON(w, s.PutRepository(&repository))
+}
+
+func (s *Server) handleRepository(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/repositories/")
+
+	existing := s.Repository(id)
+	if existing == nil {
+		writeError(w, http.StatusNotFound, "Repository not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, existing)
+	case http.MethodPut:
+		var repository client.Repository
+		if !decode(w, r, &repository) {
+			return
+		}
+		repository.ID = id
+		writeJSON(w, s.PutRepository(&repository))
+	case http.MethodDelete:
+		s.DeleteRepository(id)
+		writeJSON(w, existing)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// writePage answers a page of items, keyed by ID, in the order of their IDs.
+// The cursor is the ID of the last item of the previous page.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items map[string]interface{}) {
+	limit := s.PageSize
+	if limit < 1 {
+		limit = DefaultPageSize
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		requested, err := strconv.Atoi(value)
+		if err != nil || requested < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		if requested < limit {
+			limit = requested
+		}
+	}
+	cursor := r.URL.Query().Get("cursor")
+
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		if id > cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	nextCursor := ""
+	if len(ids) > limit {
+		ids = ids[:limit]
+		nextCursor = ids[limit-1]
+	}
+
+	page := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		page = append(page, items[id])
+	}
+
+	writeJSON(w, map[string]interface{}{"items": page, "nextCursor": nextCursor})
+}
+
+// repositoryByURL returns a copy of the repository with repositoryURL, or nil.
+func (s *Server) repositoryByURL(repositoryURL string) *client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, repository := range s.repositories {
+		if repository.Url == repositoryURL {
+			copied := *repository
+			return &copied
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) handleCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		items := map[string]interface{}{}
+		for _, credential := range s.Credentials() {
+			items[credential.ID] = withoutSecret(credential)
+		}
+		s.writePage(w, r, items)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var credential client.Credential
+	if !decode(w, r, &credential) {
+		return
+	}
+	if credential.Name == "" || credential.Type == "" || credential.Secret == "" {
+		writeError(w, http.StatusBadRequest, "Credential name, type and secret are required")
+		return
+	}
+
+	credential.ID = ""
+	writeJSON(w, withoutSecret(s.PutCredential(&credential)))
+}
+
+// handleCredential serves a credential by its ID, and /validate below it. The
+// secret is never answered back.
+func (s *Server) handleCredential(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/credentials/")
+	id, validate := cutSuffix(id, "/validate")
+
+	existing := s.Credential(id)
+	if existing == nil {
+		writeError(w, http.StatusNotFound, "Credential not found")
+		return
+	}
+
+	if validate {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		validation := client.CredentialValidation{Valid: true}
+		if s.CheckCredential != nil {
+			if err := s.CheckCredential(existing); err != nil {
+				validation = client.CredentialValidation{Valid: false, Message: err.Error()}
+			}
+		}
+		writeJSON(w, validation)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, withoutSecret(existing))
+	case http.MethodPut:
+		var credential client.Credential
+		if !decode(w, r, &credential) {
+			return
+		}
+		credential.ID = id
+		if credential.Secret == "" {
+			credential.Secret = existing.Secret
+		}
+		writeJSON(w, withoutSecret(s.PutCredential(&credential)))
+	case http.MethodDelete:
+		s.DeleteCredential(id)
+		writeJSON(w, withoutSecret(existing))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// assignNestedIDs keeps the ID of every nested item of template that matches
+// one of previous, and gives a new ID to the others.
+func assignNestedIDs(template *client.Template, previous *client.Template) {
+	known := map[string]bool{}
+	if previous != nil {
+		for _, action := range previous.Actions {
+			known[action.ID] = true
+		}
+		for _, variable := range previous.Variables {
+			known[variable.ID] = true
+		}
+		for _, permission := range previous.Permissions {
+			known[permission.ID] = true
+		}
+	}
+
+	for _, action := range template.Actions {
+		if !known[action.ID] {
+			action.ID = newID()
+		}
+	}
+	for _, variable := range template.Variables {
+		if !known[variable.ID] {
+			variable.ID = newID()
+		}
+	}
+	for _, permission := range template.Permissions {
+		if !known[permission.ID] {
+			permission.ID = newID()
+		}
+	}
+}
+
+func copyTemplate(template *client.Template) *client.Template {
+	copied := *template
+
+	copied.Actions = make([]*client.Action, 0, len(template.Actions))
+	for _, action := range template.Actions {
+		a := *action
+		copied.Actions = append(copied.Actions, &a)
+	}
+
+	copied.Variables = make([]*client.Variable, 0, len(template.Variables))
+	for _, variable := range template.Variables {
+		v := *variable
+		copied.Variables = append(copied.Variables, &v)
+	}
+
+	copied.Permissions = make([]*client.Permissions, 0, len(template.Permissions))
+	for _, permission := range template.Permissions {
+		p := *permission
+		copied.Permissions = append(copied.Permissions, &p)
+	}
+
+	return &copied
+}
+
+func withoutSecret(credential *client.Credential) *client.Credential {
+	copied := *credential
+	copied.Secret = ""
+	return &copied
+}
+
+func cutSuffix(s string, suffix string) (string, bool) {
+	if !strings.HasSuffix(s, suffix) {
+		return s, false
+	}
+	return strings.TrimSuffix(s, suffix), true
+}
+
+func newID() string {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers in the format the client decodes into ResponseError.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&client.ResponseError{Message: message})
+}
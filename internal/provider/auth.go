@@ -1,69 +1,91 @@
 package provider
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
-	"time"
-)
+	"net"
+	"net/url"
+	"strings"
 
-type LoginRequestPayload struct {
-	Username     string `json:"username"`
-	Password     string `json:"password"`
-	Organization string `json:"organization"`
-}
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 
-type LoginResponse struct {
-	SessionToken string `json:"sessionToken"`
-}
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
 
-func getSessionToken(base_url string, username string, password string, organization string) string {
-	log.Print("Getting token")
-	client := &http.Client{Timeout: 10 * time.Second}
+// loginDiagnostics turns an error returned by client.Login into a diagnostic
+// that tells the user which part of the provider configuration to fix.
+func loginDiagnostics(err error, apiEndpoint string, organization string) diag.Diagnostics {
+	var summary, detail string
 
-	payload := &LoginRequestPayload{
-		Username:     username,
-		Password:     password,
-		Organization: organization,
-	}
+	var unreachableErr *client.UnreachableError
+	var decodeErr *client.DecodeError
+	var respErr *client.ResponseError
 
-	requestBody, err := json.Marshal(payload)
-	if err != nil {
-		log.Print(err)
-		return "Error during login - before building request"
+	switch {
+	case errors.Is(err, client.ErrInvalidCredentials):
+		summary = "Invalid Nyno credentials"
+		detail = fmt.Sprintf("The Nyno API rejected the username or password (%s). Check the username and password provider attributes or the NYNO_USERNAME and NYNO_PASSWORD environment variables.", err)
+	case errors.Is(err, client.ErrUnknownOrganization):
+		summary = "Unknown Nyno organization"
+		detail = fmt.Sprintf("The organization %q does not exist at %s (%s). Check the organization provider attribute or the NYNO_ORGANIZATION environment variable.", organization, apiEndpoint, err)
+	case errors.As(err, &unreachableErr):
+		summary = "Unable to reach the Nyno API"
+		detail = fmt.Sprintf("%s. Check the api_endpoint provider attribute or the NYNO_API_ENDPOINT environment variable.", err)
+	case errors.As(err, &decodeErr):
+		summary = "Unexpected login response from the Nyno API"
+		detail = fmt.Sprintf("%s. Check that %s points to the Nyno API.", err, apiEndpoint)
+	case errors.As(err, &respErr):
+		summary = "Unable to log in to the Nyno API"
+		detail = respErr.Error()
+	default:
+		summary = "Unable to log in to the Nyno API"
+		detail = err.Error()
 	}
-	body := bytes.NewBuffer(requestBody)
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%[1]s/auth/credentials", base_url), body)
-
-	if err != nil {
-		return "Error during login - before request"
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   detail,
+		},
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	r, err := client.Do(req)
-	if err != nil {
-		return "Error during login - after request"
+// checkEndpoint refuses an api_endpoint that would send credentials in clear,
+// that is a plain http:// URL to anything but the local machine, unless
+// allowInsecureHTTP is set.
+func checkEndpoint(apiEndpoint string, allowInsecureHTTP bool) diag.Diagnostics {
+	u, err := url.Parse(apiEndpoint)
+	if err != nil || u.Host == "" {
+		return diag.Errorf("api_endpoint %q is not a valid URL", apiEndpoint)
 	}
 
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return fmt.Sprintf("Unable to update role. Status Code: %v", r.StatusCode)
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return nil
+	case "http":
+		if allowInsecureHTTP || isLoopback(u.Hostname()) {
+			return nil
 		}
+	default:
+		return diag.Errorf("api_endpoint %q must use https", apiEndpoint)
+	}
 
-		return fmt.Sprintf("Unable to update role. Status Code: %v. Message: %s", r.StatusCode, response.Error)
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  "Refusing to send Nyno credentials over plain HTTP",
+			Detail: fmt.Sprintf("The api_endpoint %s does not use TLS, so the password, API token and session cookie would be readable by anyone on the network path to %s. "+
+				"Use an https:// endpoint, or set allow_insecure_http = true if the network is trusted.", apiEndpoint, u.Hostname()),
+		},
 	}
+}
 
-	var response LoginResponse
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return "Unable to decode response"
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
 	}
 
-	return response.SessionToken
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		apiEndpoint       string
+		allowInsecureHTTP bool
+		// err is part of the summary of the expected error, empty if the
+		// endpoint is accepted.
+		err string
+	}{
+		{"https://nyno.example.com", false, ""},
+		{"HTTPS://nyno.example.com:8443/api", false, ""},
+		{"http://nyno.example.com", false, "Refusing to send Nyno credentials over plain HTTP"},
+		{"http://10.0.0.1:8080", false, "Refusing to send Nyno credentials over plain HTTP"},
+		{"http://nyno.example.com", true, ""},
+		{"http://localhost:8080", false, ""},
+		{"http://LOCALHOST", false, ""},
+		{"http://127.0.0.1:8080", false, ""},
+		{"http://127.1.2.3", false, ""},
+		{"http://[::1]:8080", false, ""},
+		{"ftp://nyno.example.com", false, "must use https"},
+		{"ftp://nyno.example.com", true, "must use https"},
+		{"nyno.example.com", false, "is not a valid URL"},
+		{"https://nyno example.com/%zz", false, "is not a valid URL"},
+		{"", false, "is not a valid URL"},
+	} {
+		diags := checkEndpoint(tc.apiEndpoint, tc.allowInsecureHTTP)
+
+		if tc.err == "" {
+			if diags.HasError() {
+				t.Errorf("%q (allow_insecure_http = %v): expected no error, got %s", tc.apiEndpoint, tc.allowInsecureHTTP, diags[0].Summary)
+			}
+			continue
+		}
+		if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.err) {
+			t.Errorf("%q (allow_insecure_http = %v): expected an error containing %q, got %v", tc.apiEndpoint, tc.allowInsecureHTTP, tc.err, diags)
+		}
+	}
+}
+
+func TestCheckEndpoint_detail(t *testing.T) {
+	diags := checkEndpoint("http://nyno.example.com", false)
+	if len(diags) != 1 {
+		t.Fatalf("expected a single diagnostic, got %v", diags)
+	}
+
+	// The diagnostic tells why the endpoint is refused and how to proceed.
+	for _, s := range []string{"does not use TLS", "readable by anyone on the network path to nyno.example.com", "https://", "allow_insecure_http = true"} {
+		if !strings.Contains(diags[0].Detail, s) {
+			t.Errorf("expected the detail to contain %q, got %q", s, diags[0].Detail)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	defaultProfile         = "default"
+	defaultAPIEndpoint     = "https://nyno.io/api"
+	defaultCredentialsFile = "~/.nyno/credentials"
+	sessionCacheFile       = "~/.nyno/cache/sessions.json"
+)
+
+// providerCredentials holds the connection settings of the provider once the
+// configuration, the environment and the credentials file have been merged.
+type providerCredentials struct {
+	api_endpoint string
+	username     string
+	password     string
+	organization string
+	api_token    string
+}
+
+// resolveCredentials merges the connection settings. Values set in the
+// provider block win over the NYNO_* environment variables, which win over the
+// selected profile of the credentials file. The profile is taken from the
+// profile attribute, then NYNO_PROFILE, then "default"; only an explicitly
+// selected profile or credentials file has to exist.
+func resolveCredentials(d *schema.ResourceData) (*providerCredentials, diag.Diagnostics) {
+	creds := &providerCredentials{
+		api_endpoint: d.Get("api_endpoint").(string),
+		username:     d.Get("username").(string),
+		password:     d.Get("password").(string),
+		organization: d.Get("organization").(string),
+		api_token:    d.Get("api_token").(string),
+	}
+
+	profile := d.Get("profile").(string)
+	path := d.Get("shared_credentials_file").(string)
+	explicit := profile != "" || path != ""
+
+	if profile == "" {
+		profile = defaultProfile
+	}
+	if path == "" {
+		path = defaultCredentialsFile
+	}
+
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	profiles, err := readCredentialsFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return withDefaults(creds), nil
+	}
+	if err != nil {
+		return nil, diag.Errorf("Unable to read Nyno credentials file %s: %s", path, err)
+	}
+
+	values, ok := profiles[profile]
+	if !ok {
+		if !explicit {
+			return withDefaults(creds), nil
+		}
+		return nil, diag.Errorf("Profile %q not found in Nyno credentials file %s", profile, path)
+	}
+
+	setIfEmpty(&creds.api_endpoint, values["api_endpoint"])
+	setIfEmpty(&creds.organization, values["organization"])
+
+	// Credentials come from the profile as a whole, so that a profile holding an
+	// api_token does not mix with a username set elsewhere and vice versa.
+	if creds.api_token == "" && creds.username == "" && creds.password == "" {
+		creds.api_token = values["api_token"]
+		creds.username = values["username"]
+		creds.password = values["password"]
+	}
+
+	return withDefaults(creds), nil
+}
+
+func withDefaults(creds *providerCredentials) *providerCredentials {
+	setIfEmpty(&creds.api_endpoint, defaultAPIEndpoint)
+	return creds
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to expand %s: %w", path, err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// readCredentialsFile parses an INI style file with one section per profile:
+//
+//	[default]
+//	api_endpoint = https://nyno.io/api
+//	organization = customer
+//	username     = someuser
+//	password     = secret
+//
+// Lines starting with # or ; are comments.
+func readCredentialsFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles := map[string]map[string]string{}
+	var current map[string]string
+
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := profiles[name]; !ok {
+				profiles[name] = map[string]string{}
+			}
+			current = profiles[name]
+		default:
+			key, value, ok := cut(line, "=")
+			if !ok || current == nil {
+				return nil, fmt.Errorf("line %d: expected a [profile] header or a key = value pair", lineNumber)
+			}
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return profiles, scanner.Err()
+}
+
+func cut(s string, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testCredentialsHome clears the NYNO_* variables and points HOME to an empty
+// directory, returned so that tests can write ~/.nyno/credentials there.
+func testCredentialsHome(t *testing.T) string {
+	t.Helper()
+
+	for _, name := range []string{"NYNO_API_ENDPOINT", "NYNO_USERNAME", "NYNO_PASSWORD", "NYNO_ORGANIZATION", "NYNO_API_TOKEN", "NYNO_PROFILE", "NYNO_SHARED_CREDENTIALS_FILE"} {
+		t.Setenv(name, "")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	return home
+}
+
+// testWriteCredentialsFile writes content to path, creating its directory.
+func testWriteCredentialsFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testResolveCredentials(t *testing.T, raw map[string]interface{}) (*providerCredentials, diag.Diagnostics) {
+	t.Helper()
+
+	return resolveCredentials(schema.TestResourceDataRaw(t, Provider("test").Schema, raw))
+}
+
+func TestResolveCredentials_precedence(t *testing.T) {
+	home := testCredentialsHome(t)
+	testWriteCredentialsFile(t, filepath.Join(home, ".nyno", "credentials"), `
+[default]
+api_endpoint = https://file.example.com/api
+organization = file-organization
+username     = file-user
+password     = file-password
+`)
+	t.Setenv("NYNO_API_ENDPOINT", "https://env.example.com/api")
+	t.Setenv("NYNO_ORGANIZATION", "env-organization")
+
+	creds, diags := testResolveCredentials(t, map[string]interface{}{
+		"organization": "block-organization",
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := &providerCredentials{
+		api_endpoint: "https://env.example.com/api",
+		organization: "block-organization",
+		username:     "file-user",
+		password:     "file-password",
+	}
+	if !reflect.DeepEqual(creds, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, creds)
+	}
+}
+
+func TestResolveCredentials_profileCredentialsAsWhole(t *testing.T) {
+	home := testCredentialsHome(t)
+	testWriteCredentialsFile(t, filepath.Join(home, ".nyno", "credentials"), `
+[default]
+username  = file-user
+password  = file-password
+
+[token]
+api_token = file-token
+`)
+
+	for _, tc := range []struct {
+		name     string
+		raw      map[string]interface{}
+		env      map[string]string
+		expected providerCredentials
+	}{
+		{
+			name:     "username in the block",
+			raw:      map[string]interface{}{"username": "block-user"},
+			expected: providerCredentials{username: "block-user"},
+		},
+		{
+			name:     "api_token in the environment",
+			env:      map[string]string{"NYNO_API_TOKEN": "env-token"},
+			expected: providerCredentials{api_token: "env-token"},
+		},
+		{
+			name:     "username and password in the block, api_token in the profile",
+			raw:      map[string]interface{}{"profile": "token", "username": "block-user", "password": "block-password"},
+			expected: providerCredentials{username: "block-user", password: "block-password"},
+		},
+		{
+			name:     "nothing set",
+			raw:      map[string]interface{}{"profile": "token"},
+			expected: providerCredentials{api_token: "file-token"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			creds, diags := testResolveCredentials(t, tc.raw)
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			tc.expected.api_endpoint = defaultAPIEndpoint
+			if *creds != tc.expected {
+				t.Fatalf("expected %+v, got %+v", tc.expected, *creds)
+			}
+		})
+	}
+}
+
+func TestResolveCredentials_defaultsMissing(t *testing.T) {
+	home := testCredentialsHome(t)
+	expected := &providerCredentials{api_endpoint: defaultAPIEndpoint, username: "block-user"}
+
+	// Neither the default file nor, once it exists, the default profile have
+	// to exist.
+	for _, content := range []string{"", "[production]\nusername = file-user\n"} {
+		if content != "" {
+			testWriteCredentialsFile(t, filepath.Join(home, ".nyno", "credentials"), content)
+		}
+
+		creds, diags := testResolveCredentials(t, map[string]interface{}{"username": "block-user"})
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if !reflect.DeepEqual(creds, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, creds)
+		}
+	}
+}
+
+func TestResolveCredentials_explicitMissing(t *testing.T) {
+	home := testCredentialsHome(t)
+	path := filepath.Join(home, ".nyno", "credentials")
+
+	for _, tc := range []struct {
+		name string
+		raw  map[string]interface{}
+		env  map[string]string
+		err  string
+	}{
+		{
+			name: "profile without a file",
+			raw:  map[string]interface{}{"profile": "production"},
+			err:  "Unable to read Nyno credentials file",
+		},
+		{
+			name: "file",
+			raw:  map[string]interface{}{"shared_credentials_file": filepath.Join(home, "missing")},
+			err:  "Unable to read Nyno credentials file",
+		},
+		{
+			name: "profile",
+			raw:  map[string]interface{}{"profile": "production"},
+			err:  `Profile "production" not found in Nyno credentials file`,
+		},
+		{
+			name: "profile from NYNO_PROFILE",
+			env:  map[string]string{"NYNO_PROFILE": "production"},
+			err:  `Profile "production" not found in Nyno credentials file`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if strings.HasPrefix(tc.err, "Profile") {
+				testWriteCredentialsFile(t, path, "[default]\nusername = file-user\n")
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			_, diags := testResolveCredentials(t, tc.raw)
+			if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, diags)
+			}
+		})
+	}
+}
+
+func TestReadCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	testWriteCredentialsFile(t, path, `
+# Nyno credentials
+[default]
+  username = someuser
+; password = old-secret
+password=p@ss=word
+
+[ production ]
+api_token = token # not a comment
+
+[default]
+organization = customer
+`)
+
+	profiles, err := readCredentialsFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]map[string]string{
+		"default":    {"username": "someuser", "password": "p@ss=word", "organization": "customer"},
+		"production": {"api_token": "token # not a comment"},
+	}
+	if !reflect.DeepEqual(profiles, expected) {
+		t.Fatalf("expected %v, got %v", expected, profiles)
+	}
+}
+
+func TestReadCredentialsFile_malformed(t *testing.T) {
+	for content, expected := range map[string]string{
+		"[default]\nusername = someuser\npassword\n": "line 3: expected a [profile] header or a key = value pair",
+		"username = someuser\n[default]\n":           "line 1: expected a [profile] header or a key = value pair",
+		"[default\nusername = someuser\n":            "line 1: expected a [profile] header or a key = value pair",
+	} {
+		path := filepath.Join(t.TempDir(), "credentials")
+		testWriteCredentialsFile(t, path, content)
+
+		if _, err := readCredentialsFile(path); err == nil || err.Error() != expected {
+			t.Errorf("%q: expected the error %q, got %v", content, expected, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceRepositories() *schema.Resource {
+	return &schema.Resource{
+		Description: "List the repositories connected to the nyno organization, optionally filtered. " +
+			"Every page of the API's list is read.",
+		ReadContext: dataSourceRepositoriesRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_active": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only list repositories that are, or are not, active.",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only list repositories whose name matches this regular expression.",
+			},
+			"url_prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only list repositories whose URL starts with this prefix, compared as is.",
+			},
+			"repositories": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":        {Type: schema.TypeString, Computed: true},
+						"name":      {Type: schema.TypeString, Computed: true},
+						"url":       {Type: schema.TypeString, Computed: true},
+						"is_active": {Type: schema.TypeBool, Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceRepositoriesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	repositories, err := meta.(Config).client.ListRepositories(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to list repositories. %s", err)
+	}
+
+	// is_active false is a filter too, so look at the configuration rather
+	// than at the zero value.
+	isActive := d.GetRawConfig().GetAttr("is_active")
+	var nameRegex *regexp.Regexp
+	if value, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(value.(string))
+	}
+	urlPrefix := d.Get("url_prefix").(string)
+
+	items := []interface{}{}
+	var ids []string
+	for _, repository := range repositories {
+		if !isActive.IsNull() && repository.IsActive != isActive.True() {
+			continue
+		}
+		if nameRegex != nil && !nameRegex.MatchString(repository.Name) {
+			continue
+		}
+		if !strings.HasPrefix(repository.Url, urlPrefix) {
+			continue
+		}
+
+		items = append(items, map[string]interface{}{
+			"id":        repository.ID,
+			"name":      repository.Name,
+			"url":       repository.Url,
+			"is_active": repository.IsActive,
+		})
+		ids = append(ids, repository.ID)
+	}
+
+	if err := d.Set("repositories", items); err != nil {
+		return diag.Errorf("Unable to read repositories. %s", err)
+	}
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(ids, ",")))))
+
+	return nil
+}
@@ -2,39 +2,41 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
 	"net/url"
-	"time"
+	"regexp"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-)
 
-type Repository struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Url      string `json:"url"`
-	IsActive bool   `json:"isActive"`
-}
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
 
 func dataSourceRepository() *schema.Resource {
 	return &schema.Resource{
+		Description: "Look up a repository connected to the nyno organization by exactly one of `id`, `name` or `url`.\n\n" +
+			"A `url` matches the repository whatever its form: a trailing slash or `.git` suffix, and SSH " +
+			"(`git@github.com:org/repo.git`) versus HTTPS (`https://github.com/org/repo`) URLs make no difference. " +
+			"The lookup fails if no repository, or more than one, matches.",
 		ReadContext: dataSourceRepositoryRead,
 		Schema: map[string]*schema.Schema{
 			"id": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "name", "url"},
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "name", "url"},
 			},
 			"url": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "name", "url"},
 			},
 			"is_active": {
 				Type:     schema.TypeBool,
@@ -44,53 +46,83 @@ func dataSourceRepository() *schema.Resource {
 	}
 }
 
-func resourceRepositoryRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	log.Print("INREPOREAD")
-	log.Print(fmt.Sprintf("%[1]s/repositories/%[2]s", m.(Config).api_endpoint, url.QueryEscape(d.Id())))
-	req, err := http.NewRequest("GET", fmt.Sprintf("%[1]s/repositories/%[2]s", m.(Config).api_endpoint, url.QueryEscape(d.Get("url").(string))), nil)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+func dataSourceRepositoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c := meta.(Config).client
 
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s" ,m.(Config).session_token))
+	var repository *client.Repository
+	if id, ok := d.GetOk("id"); ok {
+		var err error
+		repository, err = c.GetRepository(ctx, id.(string))
+		if client.IsNotFound(err) {
+			return diag.Errorf("Unable to read repository. No repository has id %q", id)
+		}
+		if err != nil {
+			return diag.Errorf("Unable to read repository. %s", err)
+		}
+	} else {
+		repositories, err := c.ListRepositories(ctx)
+		if err != nil {
+			return diag.Errorf("Unable to list repositories. %s", err)
+		}
 
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+		attribute, value := "url", d.Get("url").(string)
+		match := func(repository *client.Repository) bool {
+			return normalizeRepositoryURL(repository.Url) == normalizeRepositoryURL(value)
+		}
+		if name, ok := d.GetOk("name"); ok {
+			attribute, value = "name", name.(string)
+			match = func(repository *client.Repository) bool {
+				return repository.Name == value
+			}
+		}
 
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-		if response == nil {
-			return diag.Errorf("Unable to read repository. Status Code: %v", r.StatusCode)
+		var matches []*client.Repository
+		var ids []string
+		for _, repository := range repositories {
+			if match(repository) {
+				matches = append(matches, repository)
+				ids = append(ids, repository.ID)
+			}
 		}
-		return diag.Errorf("Unable to read repository. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
 
-	var response Repository
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return diag.FromErr(err)
+		switch len(matches) {
+		case 0:
+			return diag.Errorf("Unable to read repository. No repository has %s %q", attribute, value)
+		case 1:
+			repository = matches[0]
+		default:
+			return diag.Errorf("Unable to read repository. %d repositories have %s %q, look one up by id instead: %s",
+				len(matches), attribute, value, strings.Join(ids, ", "))
+		}
 	}
 
-	d.Set("name", response.Name)
-	d.Set("url", response.Url)
-	d.Set("is_active", response.IsActive)
-	d.Set("id", response.ID)
-	d.SetId(response.ID)
+	d.Set("name", repository.Name)
+	d.Set("url", repository.Url)
+	d.Set("is_active", repository.IsActive)
+	d.SetId(repository.ID)
 
 	return nil
 }
 
-func dataSourceRepositoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+// scpLikeURL matches the short SSH form of Git URLs, e.g.
+// git@github.com:nyno-app/example.git.
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
 
-	err := resourceRepositoryRead(ctx, d, meta)
-	if err != nil {
-		return err
+// normalizeRepositoryURL reduces the forms a Git URL can take to host/path, so
+// that https://github.com/org/repo, https://github.com/org/repo.git/ and
+// git@github.com:org/repo.git compare equal.
+func normalizeRepositoryURL(repositoryURL string) string {
+	repositoryURL = strings.TrimSpace(repositoryURL)
+
+	var host, path string
+	if u, err := url.Parse(repositoryURL); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if m := scpLikeURL.FindStringSubmatch(repositoryURL); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return repositoryURL
 	}
 
-	return nil
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return strings.ToLower(host) + "/" + strings.TrimSuffix(path, "/")
 }
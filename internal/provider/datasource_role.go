@@ -46,12 +46,18 @@ func dataSourceRole() *schema.Resource {
 }
 
 func dataSourceRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	d.SetId(d.Get("id").(string))
+	id := d.Get("id").(string)
+	d.SetId(id)
 
 	err := resourceRoleRead(ctx, d, meta)
 	if err != nil {
 		return err
 	}
 
+	// resourceRoleRead clears the ID of a role that does not exist.
+	if d.Id() == "" {
+		return diag.Errorf("Unable to read role. Role %s not found", id)
+	}
+
 	return nil
 }
@@ -123,11 +123,23 @@ func dataSourceTemplate() *schema.Resource {
 }
 
 func dataSourceTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	d.SetId(d.Get("id").(string))
-
-	err := resourceTemplateRead(ctx, d, meta)
+	response, err := meta.(Config).client.GetTemplate(ctx, d.Get("id").(string))
 	if err != nil {
-		return err
+		return diag.Errorf("Unable to read template. %s", err)
+	}
+
+	d.SetId(response.ID)
+	d.Set("name", response.Name)
+	d.Set("description", response.Description)
+
+	if err := d.Set("actions", flattenActions(response.Actions)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("variables", flattenVariables(response.Variables)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("permissions", flattenPermissions(response.Permissions)); err != nil {
+		return diag.FromErr(err)
 	}
 
 	return nil
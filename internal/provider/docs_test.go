@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// docsAttributePattern matches an attribute of the schema section of a page
+// generated by tfplugindocs, e.g. - `name` (String) The name.
+var docsAttributePattern = regexp.MustCompile("^- `(\\w+)` \\(([^)]*)\\)(?: (.*))?$")
+
+// TestDocs checks that the top-level attributes in docs/ are described as in
+// the schema, from which tfplugindocs generates the pages, so that publishing
+// the docs never drops a description.
+func TestDocs(t *testing.T) {
+	p := Provider("test")
+
+	testDocsSchema(t, "../../docs/index.md", p.Schema)
+	for name, r := range p.ResourcesMap {
+		path := fmt.Sprintf("../../docs/resources/%s.md", strings.TrimPrefix(name, "nyno_"))
+		testDocsDescription(t, path, r.Description)
+		testDocsSchema(t, path, r.Schema)
+	}
+	for name, r := range p.DataSourcesMap {
+		path := fmt.Sprintf("../../docs/data-sources/%s.md", strings.TrimPrefix(name, "nyno_"))
+		testDocsDescription(t, path, r.Description)
+		testDocsSchema(t, path, r.Schema)
+	}
+}
+
+// testDocsDescription checks the text under the title of the page, compared
+// regardless of how it is wrapped.
+func testDocsDescription(t *testing.T, path string, description string) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text []string
+	title := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "# nyno_") {
+			title = true
+			continue
+		}
+		if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "<!--") {
+			break
+		}
+		if title {
+			text = append(text, line)
+		}
+	}
+
+	if got, expected := strings.Join(strings.Fields(strings.Join(text, " ")), " "), strings.Join(strings.Fields(description), " "); got != expected {
+		t.Errorf("%s: the page is described as\n\t%q\nbut the schema as\n\t%q", path, got, expected)
+	}
+}
+
+func testDocsSchema(t *testing.T, path string, attributes map[string]*schema.Schema) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		// Nested schemas come after the top-level attributes.
+		if strings.HasPrefix(line, "<a id=") || strings.HasPrefix(line, "## Import") {
+			break
+		}
+
+		match := docsAttributePattern.FindStringSubmatch(line)
+		if match == nil || match[1] == "timeouts" {
+			continue
+		}
+		name, description := match[1], match[3]
+
+		attribute, ok := attributes[name]
+		switch {
+		case !ok:
+			t.Errorf("%s: %s is not in the schema", path, name)
+		case strings.HasPrefix(description, "(see [below"):
+		case name == "id" && attribute.Description == "" && description == "The ID of this resource.":
+		case description != attribute.Description:
+			t.Errorf("%s: %s is described as\n\t%q\nin the docs but as\n\t%q\nin the schema", path, name, description, attribute.Description)
+		}
+	}
+}
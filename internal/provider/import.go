@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
+
+// importNamePrefix marks an import ID that is the name of the object rather
+// than its UUID, e.g. `terraform import nyno_role.admins name:Admins`.
+const importNamePrefix = "name:"
+
+// importName returns the name in an import ID of the form name:<name>.
+func importName(id string) (string, bool) {
+	if !strings.HasPrefix(id, importNamePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(id, importNamePrefix), true
+}
+
+// namedObject is the ID and name of an object that can be imported by name.
+type namedObject struct {
+	ID   string
+	Name string
+}
+
+// importByName returns the importer of the resources of kind. It accepts
+// either the UUID of an object or name:<name>, which is resolved to the UUID of
+// the only object with that name among those returned by list.
+func importByName(kind string, list func(ctx context.Context, c *client.Client) ([]namedObject, error)) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+		name, byName := importName(d.Id())
+		if !byName {
+			return []*schema.ResourceData{d}, nil
+		}
+
+		objects, err := list(ctx, m.(Config).client)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to list %s. %s", plural(kind), err)
+		}
+
+		var ids []string
+		for _, object := range objects {
+			if object.Name == name {
+				ids = append(ids, object.ID)
+			}
+		}
+
+		id, err := uniqueImportID(kind, name, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		d.SetId(id)
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
+// uniqueImportID returns the only ID in ids, the IDs of the objects of kind
+// named name, so that an import by name never picks an object at random.
+func uniqueImportID(kind string, name string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("Unable to import %s. No %s is named %q", kind, kind, name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("Unable to import %s. %d %s are named %q, import one of them by ID instead: %s",
+			kind, len(ids), plural(kind), name, strings.Join(ids, ", "))
+	}
+}
+
+// plural returns the plural of kind, e.g. repositories for repository.
+func plural(kind string) string {
+	switch {
+	case strings.HasSuffix(kind, "s"):
+		return kind
+	case strings.HasSuffix(kind, "y"):
+		return strings.TrimSuffix(kind, "y") + "ies"
+	default:
+		return kind + "s"
+	}
+}
@@ -2,69 +2,273 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
 )
 
 type Config struct {
-	api_endpoint  string
-	session_token string
+	client *client.Client
 }
 
-func Provider() *schema.Provider {
-	return &schema.Provider{
-		ConfigureContextFunc: providerConfigure,
+func Provider(version string) *schema.Provider {
+	p := &schema.Provider{
 		ResourcesMap: map[string]*schema.Resource{
-			"nyno_template": resourceTemplate(),
-			"nyno_role":     resourceRole(),
+			"nyno_template":    resourceTemplate(),
+			"nyno_role":        resourceRole(),
+			"nyno_repository":  resourceRepository(),
+			"nyno_credentials": resourceCredentials(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"nyno_template":   dataSourceTemplate(),
-			"nyno_role":       dataSourceRole(),
-			"nyno_repository": dataSourceRepository(),
+			"nyno_template":     dataSourceTemplate(),
+			"nyno_role":         dataSourceRole(),
+			"nyno_repository":   dataSourceRepository(),
+			"nyno_repositories": dataSourceRepositories(),
 		},
 		Schema: map[string]*schema.Schema{
 			"api_endpoint": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("NYNO_API_ENDPOINT", "https://nyno.io/api"),
-				Description: "The URL to use for Nyno API",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_API_ENDPOINT", nil),
+				Description: "The URL to use for Nyno API. Can be set with `NYNO_API_ENDPOINT`, defaults to `https://nyno.io/api`.",
 			},
 			"username": {
-				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("NYNO_USERNAME", nil),
-				Description: "Username to use for Nyno API",
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("NYNO_USERNAME", nil),
+				ConflictsWith: []string{"api_token"},
+				Description:   "Username to use for Nyno API. Can be set with `NYNO_USERNAME`. Required unless `api_token` is set.",
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("NYNO_PASSWORD", nil),
-				Description: "Password to use for Nyno API",
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("NYNO_PASSWORD", nil),
+				Sensitive:     true,
+				ConflictsWith: []string{"api_token"},
+				Description:   "Password to use for Nyno API. Can be set with `NYNO_PASSWORD`. Required unless `api_token` is set.",
 			},
 			"organization": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("NYNO_ORGANIZATION", nil),
-				Description: "Organization to use for Nyno API",
+				Description: "Organization to use for Nyno API. Can be set with `NYNO_ORGANIZATION`. Required unless `api_token` is set.",
+			},
+			"api_token": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   schema.EnvDefaultFunc("NYNO_API_TOKEN", nil),
+				ConflictsWith: []string{"username", "password"},
+				Description:   "API token to use for Nyno API instead of username and password. Can be set with `NYNO_API_TOKEN`. Conflicts with `username` and `password`.",
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_PROFILE", nil),
+				Description: "Profile of the credentials file to use for Nyno API. Can be set with `NYNO_PROFILE`, defaults to `default`.",
+			},
+			"shared_credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_SHARED_CREDENTIALS_FILE", nil),
+				Description: "Path of the credentials file. Can be set with `NYNO_SHARED_CREDENTIALS_FILE`, defaults to `~/.nyno/credentials`.",
+			},
+			"session_cache": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_SESSION_CACHE", true),
+				Description: "Whether to keep the session in `~/.nyno/cache` between Terraform runs. Can be set with `NYNO_SESSION_CACHE`, defaults to `true`. The cache file is only readable by its owner and a cached session is only reused with the password it was opened with, and dropped as soon as the API rejects it.",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      client.DefaultMaxRetries,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "How many times a failed request to Nyno API is retried. Defaults to `3`. Connection errors are retried for reads, updates and deletes; `429`, `502`, `503` and `504` responses for every request; other `5xx` responses for every request but creates.",
+			},
+			"retry_max_wait": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      client.DefaultRetryMaxWait.String(),
+				ValidateFunc: validateDuration,
+				Description:  "Longest wait between two retries of a request to Nyno API, as a positive duration such as `30s`. Defaults to `30s`. Retries back off exponentially with jitter and honor the `Retry-After` header of the response.",
+			},
+			"max_requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0.0,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Average number of requests per second sent to Nyno API by all resources of the provider. Defaults to `0`, which means unlimited. Waits caused by the limit are logged at `DEBUG` level.",
+			},
+			"burst": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of requests that may be sent to Nyno API at once above `max_requests_per_second`. Defaults to `10`.",
+			},
+			"request_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      client.DefaultRequestTimeout.String(),
+				ValidateFunc: validateDuration,
+				Description:  "Timeout of a single request to Nyno API, as a positive duration such as `30s`. Defaults to `30s`. Each retry gets a fresh timeout; the operation as a whole is bounded by the `timeouts` of the resource.",
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("NYNO_CA_CERT_FILE", nil),
+				ConflictsWith: []string{"ca_cert_pem"},
+				Description:   "Path of a PEM encoded CA bundle to trust for Nyno API, in addition to the system roots. Can be set with `NYNO_CA_CERT_FILE`. Conflicts with `ca_cert_pem`.",
+			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+				Description:   "PEM encoded CA bundle to trust for Nyno API, in addition to the system roots. Conflicts with `ca_cert_file`.",
+			},
+			"client_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_CLIENT_CERT", nil),
+				Description: "PEM encoded client certificate, or the path of a file holding it, for mutual TLS with Nyno API. Can be set with `NYNO_CLIENT_CERT`. Requires `client_key`.",
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_CLIENT_KEY", nil),
+				Description: "PEM encoded private key of `client_cert`, or the path of a file holding it. Can be set with `NYNO_CLIENT_KEY`. Requires `client_cert`.",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_INSECURE_SKIP_VERIFY", false),
+				Description: "Whether to skip the verification of the certificate of Nyno API. Can be set with `NYNO_INSECURE_SKIP_VERIFY`. Only meant for testing.",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_PROXY_URL", nil),
+				Description: "URL of the proxy to use for Nyno API, instead of the `HTTP_PROXY` and `HTTPS_PROXY` environment variables. Can be set with `NYNO_PROXY_URL`.",
+			},
+			"extra_headers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Headers added to every request to Nyno API, including the login. They cannot replace the headers carrying the credentials.",
+			},
+			"allow_insecure_http": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_ALLOW_INSECURE_HTTP", false),
+				Description: "Whether to allow an `http://` `api_endpoint` other than `localhost` or a loopback address, which sends credentials in clear. Can be set with `NYNO_ALLOW_INSECURE_HTTP`. Defaults to `false`.",
+			},
+			"debug_http": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NYNO_DEBUG_HTTP", false),
+				Description: "Whether to log the requests to Nyno API and their responses at `DEBUG` level instead of `TRACE`. Can be set with `NYNO_DEBUG_HTTP`. Credentials are masked and long values such as `template_code` are truncated in the dumps.",
 			},
 		},
 	}
+
+	p.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		// TerraformVersion is only known once Terraform configures the provider.
+		return providerConfigure(ctx, d, p.UserAgent("terraform-provider-nyno", version))
+	}
+
+	return p
 }
 
-func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
-	organization := d.Get("organization").(string)
-	api_endpoint := d.Get("api_endpoint").(string)
+func providerConfigure(ctx context.Context, d *schema.ResourceData, userAgent string) (interface{}, diag.Diagnostics) {
+	creds, diags := resolveCredentials(d)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if diags := checkEndpoint(creds.api_endpoint, d.Get("allow_insecure_http").(bool)); diags.HasError() {
+		return nil, diags
+	}
+
+	c := client.New(creds.api_endpoint)
+
+	tlsConfig, diags := buildTLSConfig(ctx, d)
+	if diags.HasError() {
+		return nil, diags
+	}
+	c.SetTLSConfig(tlsConfig)
+
+	if proxyURL := d.Get("proxy_url").(string); proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return nil, diag.Errorf("proxy_url %q is not a valid URL", proxyURL)
+		}
+		c.SetProxy(u)
+	}
+
+	extraHeaders := map[string]string{}
+	for name, value := range d.Get("extra_headers").(map[string]interface{}) {
+		extraHeaders[name] = value.(string)
+	}
+	c.SetExtraHeaders(extraHeaders)
+	c.SetUserAgent(userAgent)
+	c.SetDebugHTTP(d.Get("debug_http").(bool))
+
+	requestTimeout, _ := time.ParseDuration(d.Get("request_timeout").(string))
+	c.SetRequestTimeout(requestTimeout)
 
-	session_token := getSessionToken(api_endpoint, username, password, organization)
+	retryMaxWait, _ := time.ParseDuration(d.Get("retry_max_wait").(string))
+	c.SetRetryPolicy(d.Get("max_retries").(int), retryMaxWait)
+	c.SetRateLimit(d.Get("max_requests_per_second").(float64), d.Get("burst").(int))
+
+	if creds.api_token != "" {
+		if creds.username != "" || creds.password != "" {
+			return nil, diag.Errorf("api_token cannot be combined with username and password, set either api_token or username, password and organization")
+		}
+		c.UseAPIToken(creds.api_token)
+	} else {
+		if creds.username == "" || creds.password == "" || creds.organization == "" {
+			return nil, diag.Errorf("username, password and organization are required when api_token is not set, either in the provider configuration, the NYNO_* environment variables or a credentials file profile")
+		}
+		if d.Get("session_cache").(bool) {
+			path, err := expandHome(sessionCacheFile)
+			if err != nil {
+				return nil, diag.FromErr(err)
+			}
+			c.UseSessionCache(client.NewSessionCache(path))
+		}
+		if err := c.Login(ctx, creds.username, creds.password, creds.organization); err != nil {
+			return nil, loginDiagnostics(err, creds.api_endpoint, creds.organization)
+		}
+	}
 
 	config := Config{
-		api_endpoint:  d.Get("api_endpoint").(string),
-		session_token: session_token,
+		client: c,
 	}
 
 	return config, nil
 }
+
+func validateDuration(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+
+	duration, err := time.ParseDuration(v)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration such as 30s or 5m: %s", k, err)}
+	}
+	if duration <= 0 {
+		return nil, []error{fmt.Errorf("%s must be a positive duration such as 30s or 5m, got %s", k, v)}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,364 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+// testAccProviderFactories serves the provider in process to the Terraform CLI
+// run by resource.Test.
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"nyno": func() (*schema.Provider, error) {
+		return Provider("test"), nil
+	},
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider("test").InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestValidateDuration(t *testing.T) {
+	attributes := Provider("test").Schema
+
+	for _, k := range []string{"retry_max_wait", "request_timeout"} {
+		for v, valid := range map[string]bool{"30s": true, "1m30s": true, "0s": false, "-10s": false, "soon": false} {
+			if _, errs := attributes[k].ValidateFunc(v, k); (len(errs) == 0) != valid {
+				t.Errorf("%s = %q: expected valid to be %v, got %v", k, v, valid, errs)
+			}
+		}
+	}
+}
+
+// TestProviderConfigure_proxyAndHeaders configures the provider for an API
+// only reachable through proxy_url, and checks that the login and a resource
+// call both go through the proxy with the User-Agent and the extra headers,
+// which never replace the credentials.
+func TestProviderConfigure_proxyAndHeaders(t *testing.T) {
+	testCredentialsHome(t)
+	testClearTLSEnv(t)
+	t.Setenv("NYNO_PROXY_URL", "")
+
+	var mu sync.Mutex
+	var requests []*http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/auth/credentials":
+			w.Write([]byte(`{"sessionToken": "session"}`))
+		default:
+			w.Write([]byte(`{"id": "1", "name": "developers"}`))
+		}
+	}))
+	defer proxy.Close()
+
+	for _, tc := range []struct {
+		name          string
+		raw           map[string]interface{}
+		paths         []string
+		authorization string
+		cookie        string
+	}{
+		{
+			name:          "session",
+			raw:           map[string]interface{}{"organization": "nyno", "username": "nyno-bot", "password": "password", "session_cache": false},
+			paths:         []string{"/auth/credentials", "/roles/1"},
+			authorization: "Bearer extra-token",
+			cookie:        "next-auth.session-token=session",
+		},
+		{
+			name:          "API token",
+			raw:           map[string]interface{}{"api_token": "api-token"},
+			paths:         []string{"/roles/1"},
+			authorization: "Bearer api-token",
+			cookie:        "extra-cookie",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mu.Lock()
+			requests = nil
+			mu.Unlock()
+
+			tc.raw["api_endpoint"] = "http://nyno.example.com"
+			tc.raw["allow_insecure_http"] = true
+			tc.raw["proxy_url"] = proxy.URL
+			tc.raw["extra_headers"] = map[string]interface{}{
+				"X-Gateway-Key": "gateway-key",
+				"Authorization": "Bearer extra-token",
+				"Cookie":        "extra-cookie",
+			}
+
+			d := schema.TestResourceDataRaw(t, Provider("1.2.3").Schema, tc.raw)
+			m, diags := Provider("1.2.3").ConfigureContextFunc(context.Background(), d)
+			if diags.HasError() {
+				t.Fatalf("configure: %v", diags)
+			}
+			if _, err := m.(Config).client.GetRole(context.Background(), "1"); err != nil {
+				t.Fatalf("get role: %s", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(requests) != len(tc.paths) {
+				t.Fatalf("expected %d requests through the proxy, got %d", len(tc.paths), len(requests))
+			}
+			for i, r := range requests {
+				if r.Host != "nyno.example.com" || r.URL.Path != tc.paths[i] {
+					t.Errorf("expected a request to nyno.example.com%s through the proxy, got %s%s", tc.paths[i], r.Host, r.URL.Path)
+				}
+				if ua := r.Header.Get("User-Agent"); !strings.Contains(ua, "terraform-provider-nyno/1.2.3") {
+					t.Errorf("%s: expected the User-Agent of the provider, got %q", r.URL.Path, ua)
+				}
+				if key := r.Header.Get("X-Gateway-Key"); key != "gateway-key" {
+					t.Errorf("%s: expected the extra header, got %q", r.URL.Path, key)
+				}
+			}
+
+			last := requests[len(requests)-1]
+			if authorization := last.Header.Get("Authorization"); authorization != tc.authorization {
+				t.Errorf("expected the Authorization %q, got %q", tc.authorization, authorization)
+			}
+			if cookie := last.Header.Get("Cookie"); cookie != tc.cookie {
+				t.Errorf("expected the Cookie %q, got %q", tc.cookie, cookie)
+			}
+		})
+	}
+}
+
+// testAccServer starts a fake Nyno API closed at the end of the test. The
+// provider gets an empty home directory and no NYNO_* variable, so that the
+// credentials file and the environment of the developer stay out of the tests.
+func testAccServer(t *testing.T) *fakenyno.Server {
+	t.Helper()
+
+	testCredentialsHome(t)
+
+	server := fakenyno.NewServer()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// testAccProviderConfig configures the provider for server with the default
+// credentials of the fake. The session cache is disabled so that tests never
+// touch ~/.nyno.
+func testAccProviderConfig(server *fakenyno.Server) string {
+	return fmt.Sprintf(`
+provider "nyno" {
+  api_endpoint  = %q
+  organization  = %q
+  username      = %q
+  password      = %q
+  session_cache = false
+}
+`, server.URL, fakenyno.DefaultOrganization, fakenyno.DefaultUsername, fakenyno.DefaultPassword)
+}
+
+func TestAccProvider_invalidCredentials(t *testing.T) {
+	server := testAccServer(t)
+	server.Password = "another-password"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repository" "test" {
+  url = "https://github.com/nyno-app/example"
+}
+`,
+				ExpectError: regexp.MustCompile("Invalid Nyno credentials"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_unknownOrganization(t *testing.T) {
+	server := testAccServer(t)
+	server.Organization = "another-organization"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repository" "test" {
+  url = "https://github.com/nyno-app/example"
+}
+`,
+				ExpectError: regexp.MustCompile("Unknown Nyno organization"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_apiToken(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRoleDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "nyno" {
+  api_endpoint = %q
+  api_token    = %q
+}
+`, server.URL, fakenyno.DefaultAPIToken) + testAccRoleConfig("developers", false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRoleOnServer(server, "nyno_role.test", "developers", false),
+					func(s *terraform.State) error {
+						if n := server.Logins(); n != 0 {
+							return fmt.Errorf("expected no login with an API token, got %d", n)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// Sessions expiring does not affect the token.
+				PreConfig: server.ExpireSessions,
+				Config: fmt.Sprintf(`
+provider "nyno" {
+  api_endpoint = %q
+  api_token    = %q
+}
+`, server.URL, fakenyno.DefaultAPIToken) + testAccRoleConfig("maintainers", false),
+				Check: testAccCheckRoleOnServer(server, "nyno_role.test", "maintainers", false),
+			},
+			{
+				Config: fmt.Sprintf(`
+provider "nyno" {
+  api_endpoint = %q
+  api_token    = "another-token"
+}
+`, server.URL) + testAccRoleConfig("maintainers", false),
+				ExpectError: regexp.MustCompile("Unauthorized"),
+			},
+			{
+				Config: fmt.Sprintf(`
+provider "nyno" {
+  api_endpoint = %q
+  api_token    = %q
+}
+`, server.URL, fakenyno.DefaultAPIToken) + testAccRoleConfig("maintainers", false),
+			},
+		},
+	})
+}
+
+func TestAccProvider_sessionExpiry(t *testing.T) {
+	server := testAccServer(t)
+	server.Latency = 20 * time.Millisecond
+
+	// Logins of an apply without the sessions expiring, i.e. one per
+	// configuration of the provider.
+	var logins, baseline int
+	countLogins := func() { logins = server.Logins() }
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRoleDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccRolesConfig(20, "v1"),
+			},
+			{
+				PreConfig: countLogins,
+				Config:    testAccProviderConfig(server) + testAccRolesConfig(20, "v2"),
+				Check: func(s *terraform.State) error {
+					baseline = server.Logins() - logins
+					return nil
+				},
+			},
+			{
+				// The sessions expire among the updates, which run in
+				// parallel: the first requests to fail renew the session
+				// once, and every request succeeds.
+				PreConfig: func() {
+					countLogins()
+					server.ExpireSessionsAfter(25)
+				},
+				Config: testAccProviderConfig(server) + testAccRolesConfig(20, "v3"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("nyno_role.test.0", "name", "role-0-v3"),
+					resource.TestCheckResourceAttr("nyno_role.test.19", "name", "role-19-v3"),
+					func(s *terraform.State) error {
+						if n := server.Logins() - logins; n != baseline+1 {
+							return fmt.Errorf("expected exactly one login to renew the session, got %d logins for %d without expiry", n, baseline)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+// testAccRolesConfig declares count roles named after version.
+func testAccRolesConfig(count int, version string) string {
+	return fmt.Sprintf(`
+resource "nyno_role" "test" {
+  count = %d
+
+  name                             = "role-${count.index}-%s"
+  create_credentials               = false
+  get_credentials                  = true
+  update_credentials               = false
+  delete_credentials               = false
+  create_repository                = false
+  get_repository                   = true
+  update_repository                = false
+  delete_repository                = false
+  get_user                         = true
+  update_user                      = false
+  create_user                      = false
+  get_role                         = true
+  update_role                      = false
+  create_role                      = false
+  delete_role                      = false
+  get_all_templates                = true
+  update_all_templates             = false
+  create_templates                 = false
+  delete_all_templates             = false
+  get_all_deployments              = true
+  update_all_deployments           = false
+  create_deployments_all_templates = false
+  delete_all_deployments           = false
+  get_global_settings              = true
+  update_global_settings           = false
+}
+`, count, version)
+}
+
+// testAccWithID calls f with the ID of address in state.
+func testAccWithID(address string, f func(id string)) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		f(rs.Primary.ID)
+		return nil
+	}
+}
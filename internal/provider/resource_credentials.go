@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
+
+// expandCredential leaves the secret out unless it has to be sent, i.e. on
+// create or when rotation_trigger asks for a rotation.
+func expandCredential(d *schema.ResourceData) *client.Credential {
+	credential := &client.Credential{
+		ID:       d.Id(),
+		Name:     d.Get("name").(string),
+		Type:     d.Get("type").(string),
+		Username: d.Get("username").(string),
+	}
+	if d.IsNewResource() || d.HasChange("rotation_trigger") {
+		// Only the configuration holds the secret, see forgetSecret.
+		if secret := d.GetRawConfig().GetAttr("secret"); secret.IsKnown() && !secret.IsNull() {
+			credential.Secret = secret.AsString()
+		}
+	}
+	return credential
+}
+
+func setCredential(d *schema.ResourceData, credential *client.Credential) {
+	d.Set("name", credential.Name)
+	d.Set("type", credential.Type)
+	d.Set("username", credential.Username)
+}
+
+// forgetSecret keeps the secret out of state: state holds an empty string
+// whatever the secret, so nothing in it can be checked against a guess. As a
+// result a new secret alone makes no diff, rotation_trigger has to change too.
+func forgetSecret(interface{}) string {
+	return ""
+}
+
+// Resource schema definition
+func resourceCredentials() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manage credentials that nyno uses to work on Git repositories.\n\n" +
+			"The `secret` is sent to nyno and never read back. Terraform state keeps nothing derived from it, " +
+			"so a change to the secret alone does not show up in the plan: the secret is sent on create, and " +
+			"again whenever `rotation_trigger` changes. Imported credentials keep their secret in nyno until then.\n\n" +
+			"Unless `validate` is `false`, nyno tries the credentials against their Git provider after each " +
+			"create or update, and the apply fails if they do not work.",
+		CreateContext: resourceCredentialsCreate,
+		ReadContext:   resourceCredentialsRead,
+		UpdateContext: resourceCredentialsUpdate,
+		DeleteContext: resourceCredentialsDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importByName("credentials", listCredentialsNames),
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the credentials in nyno.",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The Git provider of the credentials, e.g. `github`.",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username of the credentials on their Git provider.",
+			},
+			"secret": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				StateFunc:   forgetSecret,
+				Description: "Password or token of the credentials. Only sent on create and when `rotation_trigger` changes, so change `rotation_trigger` along with it. Neither the secret nor anything derived from it is stored in state.",
+			},
+			"rotation_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any value. Changing it sends the secret again, e.g. after changing the secret or to undo an edit made in the nyno UI.",
+			},
+			"validate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Check that the credentials work after each create or update. Credentials that fail on create are tainted and replaced by the next apply; an update that fails keeps the prior state, so that the next apply retries it. Defaults to `true`.",
+			},
+		},
+	}
+}
+
+func resourceCredentialsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	credential, err := m.(Config).client.CreateCredential(ctx, expandCredential(d))
+	if err != nil {
+		return diag.Errorf("Unable to create credentials. %s", err)
+	}
+
+	setCredential(d, credential)
+	d.SetId(credential.ID)
+
+	// A credential that does not work is left tainted, to be replaced by the
+	// next apply.
+	return validateCredential(ctx, d, m)
+}
+
+func resourceCredentialsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	credential, err := m.(Config).client.GetCredential(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Credentials not found in Nyno, removing them from state")
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Unable to read credentials. %s", err)
+	}
+
+	setCredential(d, credential)
+
+	return nil
+}
+
+func resourceCredentialsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	credential, err := m.(Config).client.UpdateCredential(ctx, expandCredential(d))
+	if err != nil {
+		return diag.Errorf("Unable to update credentials. %s", err)
+	}
+
+	setCredential(d, credential)
+
+	// Credentials that do not work keep their prior state, so that the next
+	// plan still shows the change and the next apply sends and validates
+	// them again.
+	if diags := validateCredential(ctx, d, m); diags.HasError() {
+		d.Partial(true)
+		return diags
+	}
+
+	return nil
+}
+
+func resourceCredentialsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	err := m.(Config).client.DeleteCredential(ctx, d.Id())
+	if err != nil && !client.IsNotFound(err) {
+		return diag.Errorf("Unable to delete credentials. %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// validateCredential has Nyno try the credentials against their Git provider,
+// unless validate is false.
+func validateCredential(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if !d.Get("validate").(bool) {
+		return nil
+	}
+
+	validation, err := m.(Config).client.ValidateCredential(ctx, d.Id())
+	if err != nil {
+		return diag.Errorf("Unable to validate credentials. %s", err)
+	}
+	if !validation.Valid {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Credentials do not work",
+			Detail: fmt.Sprintf("Nyno could not use the credentials %q with %s: %s\n\n"+
+				"Fix the secret, or set validate = false to keep credentials that cannot be checked.",
+				d.Get("name"), d.Get("type"), validation.Message),
+		}}
+	}
+
+	return nil
+}
+
+// listCredentialsNames lists the credentials to import by name.
+func listCredentialsNames(ctx context.Context, c *client.Client) ([]namedObject, error) {
+	credentials, err := c.ListCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]namedObject, len(credentials))
+	for i, credential := range credentials {
+		objects[i] = namedObject{ID: credential.ID, Name: credential.Name}
+	}
+	return objects, nil
+}
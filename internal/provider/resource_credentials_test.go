@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+func TestAccCredentials_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckCredentialsDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_first", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCredentialsOnServer(server, "nyno_credentials.test", "github", "ghp_first"),
+					resource.TestCheckResourceAttrSet("nyno_credentials.test", "id"),
+					resource.TestCheckResourceAttr("nyno_credentials.test", "type", "github"),
+					// Nothing derived from the secret is kept in state.
+					resource.TestCheckResourceAttr("nyno_credentials.test", "secret", ""),
+				),
+			},
+			{
+				// A new secret alone is not sent.
+				Config:   testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_second", "1"),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccCredentialsConfig("github-bot", "ghp_second", "2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCredentialsOnServer(server, "nyno_credentials.test", "github-bot", "ghp_second"),
+					resource.TestCheckResourceAttr("nyno_credentials.test", "secret", ""),
+				),
+			},
+			{
+				// A new rotation_trigger sends the unchanged secret again,
+				// overwriting an edit made in the Nyno UI.
+				PreConfig: func() {
+					for _, credential := range server.Credentials() {
+						credential.Secret = "edited-in-ui"
+						server.PutCredential(credential)
+					}
+				},
+				Config: testAccProviderConfig(server) + testAccCredentialsConfig("github-bot", "ghp_second", "3"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCredentialsOnServer(server, "nyno_credentials.test", "github-bot", "ghp_second"),
+					resource.TestCheckResourceAttr("nyno_credentials.test", "rotation_trigger", "3"),
+				),
+			},
+			{
+				ResourceName:            "nyno_credentials.test",
+				ImportState:             true,
+				ImportStateId:           "name:github-bot",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"secret", "rotation_trigger", "validate"},
+			},
+		},
+	})
+}
+
+func TestAccCredentials_validation(t *testing.T) {
+	server := testAccServer(t)
+	server.CheckCredential = func(credential *client.Credential) error {
+		if credential.Secret != "ghp_good" {
+			return errors.New("Bad credentials")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckCredentialsDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_bad", "1"),
+				ExpectError: regexp.MustCompile(`(?s)Credentials do not work.*Bad credentials`),
+			},
+			{
+				// The credentials that failed validation are replaced.
+				Config: testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_good", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCredentialsOnServer(server, "nyno_credentials.test", "github", "ghp_good"),
+					func(s *terraform.State) error {
+						if n := len(server.Credentials()); n != 1 {
+							return fmt.Errorf("expected 1 credential in Nyno, got %d", n)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_bad", "2"),
+				ExpectError: regexp.MustCompile(`(?s)Credentials do not work.*Bad credentials`),
+			},
+			{
+				// The update that failed validation is still planned.
+				Config:             testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_bad", "2"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccCredentialsConfig("github", "ghp_good", "3"),
+				Check:  testAccCheckCredentialsOnServer(server, "nyno_credentials.test", "github", "ghp_good"),
+			},
+		},
+	})
+}
+
+func testAccCheckCredentialsOnServer(server *fakenyno.Server, address string, name string, secret string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		credential := server.Credential(rs.Primary.ID)
+		if credential == nil {
+			return fmt.Errorf("credentials %s not found in Nyno", rs.Primary.ID)
+		}
+		if credential.Name != name {
+			return fmt.Errorf("expected credentials name %q, got %q", name, credential.Name)
+		}
+		if credential.Secret != secret {
+			return fmt.Errorf("expected secret %q, got %q", secret, credential.Secret)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCredentialsDestroy(server *fakenyno.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "nyno_credentials" {
+				continue
+			}
+			if server.Credential(rs.Primary.ID) != nil {
+				return fmt.Errorf("credentials %s still exist", rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccCredentialsConfig(name string, secret string, rotationTrigger string) string {
+	return fmt.Sprintf(`
+resource "nyno_credentials" "test" {
+  name             = %q
+  type             = "github"
+  username         = "nyno-bot"
+  secret           = %q
+  rotation_trigger = %q
+}
+`, name, secret, rotationTrigger)
+}
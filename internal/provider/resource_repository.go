@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
+
+func expandRepository(d *schema.ResourceData) *client.Repository {
+	return &client.Repository{
+		ID:       d.Id(),
+		Name:     d.Get("name").(string),
+		Url:      d.Get("url").(string),
+		IsActive: d.Get("is_active").(bool),
+	}
+}
+
+func setRepository(d *schema.ResourceData, repository *client.Repository) {
+	d.Set("name", repository.Name)
+	d.Set("url", repository.Url)
+	d.Set("is_active", repository.IsActive)
+}
+
+// Resource schema definition
+func resourceRepository() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Connect a Git repository to the nyno organization.",
+		CreateContext: resourceRepositoryCreate,
+		ReadContext:   resourceRepositoryRead,
+		UpdateContext: resourceRepositoryUpdate,
+		DeleteContext: resourceRepositoryDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importByName("repository", listRepositoryNames),
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"url": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"is_active": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the repository is active in nyno. Defaults to `true`.",
+			},
+		},
+	}
+}
+
+func resourceRepositoryCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	repository, err := m.(Config).client.CreateRepository(ctx, expandRepository(d))
+	if err != nil {
+		return diag.Errorf("Unable to create repository. %s", err)
+	}
+
+	setRepository(d, repository)
+	d.SetId(repository.ID)
+
+	return nil
+}
+
+func resourceRepositoryRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	repository, err := m.(Config).client.GetRepository(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Repository not found in Nyno, removing it from state")
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Unable to read repository. %s", err)
+	}
+
+	setRepository(d, repository)
+
+	return nil
+}
+
+func resourceRepositoryUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	repository, err := m.(Config).client.UpdateRepository(ctx, expandRepository(d))
+	if err != nil {
+		return diag.Errorf("Unable to update repository. %s", err)
+	}
+
+	setRepository(d, repository)
+
+	return nil
+}
+
+func resourceRepositoryDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
+
+	err := m.(Config).client.DeleteRepository(ctx, d.Id())
+	if err != nil && !client.IsNotFound(err) {
+		return diag.Errorf("Unable to delete repository. %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// listRepositoryNames lists the repositories to import by name.
+func listRepositoryNames(ctx context.Context, c *client.Client) ([]namedObject, error) {
+	repositories, err := c.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]namedObject, len(repositories))
+	for i, repository := range repositories {
+		objects[i] = namedObject{ID: repository.ID, Name: repository.Name}
+	}
+	return objects, nil
+}
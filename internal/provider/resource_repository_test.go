@@ -0,0 +1,280 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+func TestAccRepository_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRepositoryDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccRepositoryConfig("example", true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRepositoryOnServer(server, "nyno_repository.test", "example", true),
+					resource.TestCheckResourceAttrSet("nyno_repository.test", "id"),
+					resource.TestCheckResourceAttr("nyno_repository.test", "url", testAccRepositoryURL),
+					resource.TestCheckResourceAttr("nyno_repository.test", "is_active", "true"),
+				),
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccRepositoryConfig("example-archived", false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRepositoryOnServer(server, "nyno_repository.test", "example-archived", false),
+					resource.TestCheckResourceAttr("nyno_repository.test", "name", "example-archived"),
+					resource.TestCheckResourceAttr("nyno_repository.test", "is_active", "false"),
+				),
+			},
+			{
+				ResourceName:      "nyno_repository.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "nyno_repository.test",
+				ImportState:       true,
+				ImportStateId:     "name:example-archived",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccRepository_disappears(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRepositoryDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				// A repository disconnected outside of Terraform is planned again.
+				Config:             testAccProviderConfig(server) + testAccRepositoryConfig("example", true),
+				Check:              testAccWithID("nyno_repository.test", server.DeleteRepository),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccRepositoryConfig("example", true),
+				Check:  testAccCheckRepositoryOnServer(server, "nyno_repository.test", "example", true),
+			},
+		},
+	})
+}
+
+func TestAccRepository_withTemplate(t *testing.T) {
+	server := testAccServer(t)
+
+	// The template refers to a repository created in the same apply.
+	config := testAccProviderConfig(server) +
+		testAccRepositoryConfig("example", true) +
+		testAccTemplateResourceConfig("service", "main", "nyno_repository.test.id")
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckTemplateDestroy(server),
+			testAccCheckRepositoryDestroy(server),
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTemplateOnServer(server, "nyno_template.test", "service", "main"),
+					resource.TestCheckResourceAttrPair("nyno_template.test", "action.0.repository_id", "nyno_repository.test", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRepositoryDataSource_basic(t *testing.T) {
+	server := testAccServer(t)
+	repository := server.AddRepository("example", testAccRepositoryURL, true)
+	server.AddRepository("fork", "https://github.com/someone/fork", true)
+	server.AddRepository("fork", "https://github.com/someone-else/fork", false)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repository" "test" {
+  url = "https://github.com/nyno-app/missing"
+}
+`,
+				ExpectError: regexp.MustCompile(`No repository has url "https://github.com/nyno-app/missing"`),
+			},
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repository" "test" {
+  name = "fork"
+}
+`,
+				ExpectError: regexp.MustCompile(`2 repositories have name "fork", look one up by id instead`),
+			},
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repository" "test" {
+  id   = "00000000-0000-0000-0000-000000000000"
+  name = "example"
+}
+`,
+				ExpectError: regexp.MustCompile(`only one of .id,name,url. can be specified`),
+			},
+			{
+				Config: testAccProviderConfig(server) + fmt.Sprintf(`
+data "nyno_repository" "by_id" {
+  id = %q
+}
+
+data "nyno_repository" "by_name" {
+  name = "example"
+}
+
+data "nyno_repository" "by_url" {
+  url = "git@github.com:nyno-app/example.git"
+}
+`, repository.ID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nyno_repository.by_id", "name", "example"),
+					resource.TestCheckResourceAttr("data.nyno_repository.by_id", "url", testAccRepositoryURL),
+					resource.TestCheckResourceAttr("data.nyno_repository.by_name", "id", repository.ID),
+					resource.TestCheckResourceAttr("data.nyno_repository.by_name", "is_active", "true"),
+					resource.TestCheckResourceAttr("data.nyno_repository.by_url", "id", repository.ID),
+					resource.TestCheckResourceAttr("data.nyno_repository.by_url", "url", testAccRepositoryURL),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRepositoriesDataSource_basic(t *testing.T) {
+	server := testAccServer(t)
+	// Five repositories span three pages.
+	server.PageSize = 2
+	server.AddRepository("svc-api", "https://github.com/nyno-app/api", true)
+	server.AddRepository("svc-web", "https://github.com/nyno-app/web", true)
+	server.AddRepository("svc-legacy", "https://github.com/nyno-app/legacy", false)
+	server.AddRepository("docs", "https://github.com/nyno-app/docs", true)
+	server.AddRepository("fork", "https://gitlab.com/someone/fork", true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "nyno_repositories" "all" {}
+
+data "nyno_repositories" "inactive" {
+  is_active = false
+}
+
+data "nyno_repositories" "active_services" {
+  is_active  = true
+  name_regex = "^svc-"
+  url_prefix = "https://github.com/nyno-app/"
+}
+
+data "nyno_repositories" "gitlab" {
+  url_prefix = "https://gitlab.com/"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nyno_repositories.all", "repositories.#", "5"),
+					resource.TestCheckResourceAttr("data.nyno_repositories.inactive", "repositories.#", "1"),
+					resource.TestCheckResourceAttr("data.nyno_repositories.inactive", "repositories.0.name", "svc-legacy"),
+					resource.TestCheckResourceAttr("data.nyno_repositories.inactive", "repositories.0.is_active", "false"),
+					resource.TestCheckResourceAttr("data.nyno_repositories.active_services", "repositories.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.nyno_repositories.active_services", "repositories.*", map[string]string{
+						"name": "svc-api",
+						"url":  "https://github.com/nyno-app/api",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("data.nyno_repositories.active_services", "repositories.*", map[string]string{
+						"name": "svc-web",
+					}),
+					resource.TestCheckResourceAttr("data.nyno_repositories.gitlab", "repositories.#", "1"),
+					resource.TestCheckResourceAttrSet("data.nyno_repositories.gitlab", "repositories.0.id"),
+				),
+			},
+		},
+	})
+}
+
+func TestNormalizeRepositoryURL(t *testing.T) {
+	want := "github.com/nyno-app/example"
+	for _, repositoryURL := range []string{
+		"https://github.com/nyno-app/example",
+		"https://github.com/nyno-app/example/",
+		"https://github.com/nyno-app/example.git",
+		"https://GitHub.com/nyno-app/example.git/",
+		"https://user@github.com/nyno-app/example",
+		"ssh://git@github.com/nyno-app/example.git",
+		"git@github.com:nyno-app/example.git",
+		"github.com:nyno-app/example",
+	} {
+		if got := normalizeRepositoryURL(repositoryURL); got != want {
+			t.Errorf("normalizeRepositoryURL(%q) = %q, want %q", repositoryURL, got, want)
+		}
+	}
+
+	if got := normalizeRepositoryURL("https://github.com/nyno-app/other"); got == want {
+		t.Errorf("normalizeRepositoryURL matched a different repository")
+	}
+}
+
+func testAccCheckRepositoryOnServer(server *fakenyno.Server, address string, name string, isActive bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		repository := server.Repository(rs.Primary.ID)
+		if repository == nil {
+			return fmt.Errorf("repository %s not found in Nyno", rs.Primary.ID)
+		}
+		if repository.Name != name {
+			return fmt.Errorf("expected repository name %q, got %q", name, repository.Name)
+		}
+		if repository.IsActive != isActive {
+			return fmt.Errorf("expected isActive %v, got %v", isActive, repository.IsActive)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckRepositoryDestroy(server *fakenyno.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for key, rs := range s.RootModule().Resources {
+			if rs.Type != "nyno_repository" || strings.HasPrefix(key, "data.") {
+				continue
+			}
+			if server.Repository(rs.Primary.ID) != nil {
+				return fmt.Errorf("repository %s still exists", rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccRepositoryConfig(name string, isActive bool) string {
+	return fmt.Sprintf(`
+resource "nyno_repository" "test" {
+  name      = %q
+  url       = %q
+  is_active = %t
+}
+`, name, testAccRepositoryURL, isActive)
+}
@@ -1,57 +1,94 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-
-	"fmt"
-	"log"
-	"net/http"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
 )
 
-type Role struct {
-	ID string `json:"id,omitempty"`
+func expandRole(d *schema.ResourceData) *client.Role {
+	return &client.Role{
+		ID:                            d.Id(),
+		Name:                          d.Get("name").(string),
+		CreateCredentials:             d.Get("create_credentials").(bool),
+		GetCredentials:                d.Get("get_credentials").(bool),
+		UpdateCredentials:             d.Get("update_credentials").(bool),
+		DeleteCredentials:             d.Get("delete_credentials").(bool),
+		CreateRepository:              d.Get("create_repository").(bool),
+		GetRepository:                 d.Get("get_repository").(bool),
+		UpdateRepository:              d.Get("update_repository").(bool),
+		DeleteRepository:              d.Get("delete_repository").(bool),
+		GetUser:                       d.Get("get_user").(bool),
+		UpdateUser:                    d.Get("update_user").(bool),
+		CreateUser:                    d.Get("create_user").(bool),
+		GetRole:                       d.Get("get_role").(bool),
+		UpdateRole:                    d.Get("update_role").(bool),
+		CreateRole:                    d.Get("create_role").(bool),
+		DeleteRole:                    d.Get("delete_role").(bool),
+		GetAllTemplates:               d.Get("get_all_templates").(bool),
+		UpdateAllTemplates:            d.Get("update_all_templates").(bool),
+		CreateTemplate:                d.Get("create_templates").(bool),
+		DeleteAllTemplates:            d.Get("delete_all_templates").(bool),
+		GetAllDeployments:             d.Get("get_all_deployments").(bool),
+		UpdateAllDeployments:          d.Get("update_all_deployments").(bool),
+		CreateDeploymentsAllTemplates: d.Get("create_deployments_all_templates").(bool),
+		DeleteAllDeployment:           d.Get("delete_all_deployments").(bool),
+		GetGlobalSettings:             d.Get("get_global_settings").(bool),
+		UpdateGlobalSettings:          d.Get("update_global_settings").(bool),
+	}
+}
 
-	Name                          string `json:"name"`
-	CreateCredentials             bool   `json:"createCredentials"`
-	GetCredentials                bool   `json:"getCredentials"`
-	UpdateCredentials             bool   `json:"updateCredentials"`
-	DeleteCredentials             bool   `json:"deleteCredentials"`
-	CreateRepository              bool   `json:"createRepository"`
-	GetRepository                 bool   `json:"getRepository"`
-	UpdateRepository              bool   `json:"updateRepository"`
-	DeleteRepository              bool   `json:"deleteRepository"`
-	GetUser                       bool   `json:"getUser"`
-	UpdateUser                    bool   `json:"updateUser"`
-	CreateUser                    bool   `json:"createUser"`
-	GetRole                       bool   `json:"getRole"`
-	UpdateRole                    bool   `json:"updateRole"`
-	CreateRole                    bool   `json:"createRole"`
-	DeleteRole                    bool   `json:"deleteRole"`
-	GetAllTemplates               bool   `json:"getAllTemplates"`
-	UpdateAllTemplates            bool   `json:"updateAllTemplates"`
-	CreateTemplate                bool   `json:"createTemplate"`
-	DeleteAllTemplates            bool   `json:"deleteAllTemplates"`
-	GetAllDeployments             bool   `json:"getAllDeployments"`
-	UpdateAllDeployments          bool   `json:"updateAllDeployments"`
-	CreateDeploymentsAllTemplates bool   `json:"createDeploymentsAllTemplates"`
-	DeleteAllDeployment           bool   `json:"deleteAllDeployment"`
-	GetGlobalSettings             bool   `json:"getGlobalSettings"`
-	UpdateGlobalSettings          bool   `json:"updateGlobalSettings"`
+func setRole(d *schema.ResourceData, role *client.Role) {
+	d.Set("name", role.Name)
+	d.Set("create_credentials", role.CreateCredentials)
+	d.Set("get_credentials", role.GetCredentials)
+	d.Set("update_credentials", role.UpdateCredentials)
+	d.Set("delete_credentials", role.DeleteCredentials)
+	d.Set("create_repository", role.CreateRepository)
+	d.Set("get_repository", role.GetRepository)
+	d.Set("update_repository", role.UpdateRepository)
+	d.Set("delete_repository", role.DeleteRepository)
+	d.Set("get_user", role.GetUser)
+	d.Set("update_user", role.UpdateUser)
+	d.Set("create_user", role.CreateUser)
+	d.Set("get_role", role.GetRole)
+	d.Set("update_role", role.UpdateRole)
+	d.Set("create_role", role.CreateRole)
+	d.Set("delete_role", role.DeleteRole)
+	d.Set("get_all_templates", role.GetAllTemplates)
+	d.Set("update_all_templates", role.UpdateAllTemplates)
+	d.Set("create_templates", role.CreateTemplate)
+	d.Set("delete_all_templates", role.DeleteAllTemplates)
+	d.Set("get_all_deployments", role.GetAllDeployments)
+	d.Set("update_all_deployments", role.UpdateAllDeployments)
+	d.Set("create_deployments_all_templates", role.CreateDeploymentsAllTemplates)
+	d.Set("delete_all_deployments", role.DeleteAllDeployment)
+	d.Set("get_global_settings", role.GetGlobalSettings)
+	d.Set("update_global_settings", role.UpdateGlobalSettings)
 }
 
 // Resource schema definition
 func resourceRole() *schema.Resource {
 	return &schema.Resource{
+		Description:   "Create a role in nyno.",
 		CreateContext: resourceRoleCreate,
 		ReadContext:   resourceRoleRead,
 		UpdateContext: resourceRoleUpdate,
 		DeleteContext: resourceRoleDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importByName("role", listRoleNames),
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString, // Field type
@@ -88,301 +125,70 @@ func resourceRole() *schema.Resource {
 }
 
 func resourceRoleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	role := &Role{
-		Name:                          d.Get("name").(string),
-		CreateCredentials:             d.Get("create_credentials").(bool),
-		GetCredentials:                d.Get("get_credentials").(bool),
-		UpdateCredentials:             d.Get("update_credentials").(bool),
-		DeleteCredentials:             d.Get("delete_credentials").(bool),
-		CreateRepository:              d.Get("create_repository").(bool),
-		GetRepository:                 d.Get("get_repository").(bool),
-		UpdateRepository:              d.Get("update_repository").(bool),
-		DeleteRepository:              d.Get("delete_repository").(bool),
-		GetUser:                       d.Get("get_user").(bool),
-		UpdateUser:                    d.Get("update_user").(bool),
-		CreateUser:                    d.Get("create_user").(bool),
-		GetRole:                       d.Get("get_role").(bool),
-		UpdateRole:                    d.Get("update_role").(bool),
-		CreateRole:                    d.Get("create_role").(bool),
-		DeleteRole:                    d.Get("delete_role").(bool),
-		GetAllTemplates:               d.Get("get_all_templates").(bool),
-		UpdateAllTemplates:            d.Get("update_all_templates").(bool),
-		CreateTemplate:                d.Get("create_templates").(bool),
-		DeleteAllTemplates:            d.Get("delete_all_templates").(bool),
-		GetAllDeployments:             d.Get("get_all_deployments").(bool),
-		UpdateAllDeployments:          d.Get("update_all_deployments").(bool),
-		CreateDeploymentsAllTemplates: d.Get("create_deployments_all_templates").(bool),
-		DeleteAllDeployment:           d.Get("delete_all_deployments").(bool),
-		GetGlobalSettings:             d.Get("get_global_settings").(bool),
-		UpdateGlobalSettings:          d.Get("update_global_settings").(bool),
-	}
-
-	requestBody, err := json.Marshal(role)
-
+	role, err := m.(Config).client.CreateRole(ctx, expandRole(d))
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to create role. %s", err)
 	}
 
-	body := bytes.NewBuffer(requestBody)
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/roles", m.(Config).api_endpoint), body)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s", m.(Config).session_token))
-
-	log.Println("Sending http request")
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to create role. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to create role. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	var response Role
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.Set("name", response.Name)
-	d.Set("create_credentials", response.CreateCredentials)
-	d.Set("get_credentials", response.GetCredentials)
-	d.Set("update_credentials", response.UpdateCredentials)
-	d.Set("delete_credentials", response.DeleteCredentials)
-	d.Set("create_repository", response.CreateRepository)
-	d.Set("get_repository", response.GetRepository)
-	d.Set("update_repository", response.UpdateRepository)
-	d.Set("delete_repository", response.DeleteRepository)
-	d.Set("get_user", response.GetUser)
-	d.Set("update_user", response.UpdateUser)
-	d.Set("create_user", response.CreateUser)
-	d.Set("get_role", response.GetRole)
-	d.Set("update_role", response.UpdateRole)
-	d.Set("create_role", response.CreateRole)
-	d.Set("delete_role", response.DeleteRole)
-	d.Set("get_all_templates", response.GetAllTemplates)
-	d.Set("update_all_templates", response.UpdateAllTemplates)
-	d.Set("create_templates", response.CreateTemplate)
-	d.Set("delete_all_templates", response.DeleteAllTemplates)
-	d.Set("get_all_deployments", response.GetAllDeployments)
-	d.Set("update_all_deployments", response.UpdateAllDeployments)
-	d.Set("create_deployments_all_templates", response.CreateDeploymentsAllTemplates)
-	d.Set("delete_all_deployments", response.DeleteAllDeployment)
-	d.Set("get_global_settings", response.GetGlobalSettings)
-	d.Set("update_global_settings", response.UpdateGlobalSettings)
-	d.SetId(response.ID)
+	setRole(d, role)
+	d.SetId(role.ID)
 
 	return nil
 }
 
 func resourceRoleRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	req, err := http.NewRequest("GET", fmt.Sprintf("%[1]s/roles/%[2]s", m.(Config).api_endpoint, d.Id()), nil)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s", m.(Config).session_token))
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-		if response == nil {
-			return diag.Errorf("Unable to read role. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to read role. Status Code: %v. Message: %s", r.StatusCode, response.Error)
+	role, err := m.(Config).client.GetRole(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Role not found in Nyno, removing it from state")
+		d.SetId("")
+		return nil
 	}
-
-	var response Role
-	err = json.NewDecoder(r.Body).Decode(&response)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to read role. %s", err)
 	}
 
-	d.Set("name", response.Name)
-	d.Set("create_credentials", response.CreateCredentials)
-	d.Set("get_credentials", response.GetCredentials)
-	d.Set("update_credentials", response.UpdateCredentials)
-	d.Set("delete_credentials", response.DeleteCredentials)
-	d.Set("create_repository", response.CreateRepository)
-	d.Set("get_repository", response.GetRepository)
-	d.Set("update_repository", response.UpdateRepository)
-	d.Set("delete_repository", response.DeleteRepository)
-	d.Set("get_user", response.GetUser)
-	d.Set("update_user", response.UpdateUser)
-	d.Set("create_user", response.CreateUser)
-	d.Set("get_role", response.GetRole)
-	d.Set("update_role", response.UpdateRole)
-	d.Set("create_role", response.CreateRole)
-	d.Set("delete_role", response.DeleteRole)
-	d.Set("get_all_templates", response.GetAllTemplates)
-	d.Set("update_all_templates", response.UpdateAllTemplates)
-	d.Set("create_templates", response.CreateTemplate)
-	d.Set("delete_all_templates", response.DeleteAllTemplates)
-	d.Set("get_all_deployments", response.GetAllDeployments)
-	d.Set("update_all_deployments", response.UpdateAllDeployments)
-	d.Set("create_deployments_all_templates", response.CreateDeploymentsAllTemplates)
-	d.Set("delete_all_deployments", response.DeleteAllDeployment)
-	d.Set("get_global_settings", response.GetGlobalSettings)
-	d.Set("update_global_settings", response.UpdateGlobalSettings)
+	setRole(d, role)
 
 	return nil
 }
 
 func resourceRoleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
-	role := &Role{
-		ID:                            d.Id(),
-		Name:                          d.Get("name").(string),
-		CreateCredentials:             d.Get("create_credentials").(bool),
-		GetCredentials:                d.Get("get_credentials").(bool),
-		UpdateCredentials:             d.Get("update_credentials").(bool),
-		DeleteCredentials:             d.Get("delete_credentials").(bool),
-		CreateRepository:              d.Get("create_repository").(bool),
-		GetRepository:                 d.Get("get_repository").(bool),
-		UpdateRepository:              d.Get("update_repository").(bool),
-		DeleteRepository:              d.Get("delete_repository").(bool),
-		GetUser:                       d.Get("get_user").(bool),
-		UpdateUser:                    d.Get("update_user").(bool),
-		CreateUser:                    d.Get("create_user").(bool),
-		GetRole:                       d.Get("get_role").(bool),
-		UpdateRole:                    d.Get("update_role").(bool),
-		CreateRole:                    d.Get("create_role").(bool),
-		DeleteRole:                    d.Get("delete_role").(bool),
-		GetAllTemplates:               d.Get("get_all_templates").(bool),
-		UpdateAllTemplates:            d.Get("update_all_templates").(bool),
-		CreateTemplate:                d.Get("create_templates").(bool),
-		DeleteAllTemplates:            d.Get("delete_all_templates").(bool),
-		GetAllDeployments:             d.Get("get_all_deployments").(bool),
-		UpdateAllDeployments:          d.Get("update_all_deployments").(bool),
-		CreateDeploymentsAllTemplates: d.Get("create_deployments_all_templates").(bool),
-		DeleteAllDeployment:           d.Get("delete_all_deployments").(bool),
-		GetGlobalSettings:             d.Get("get_global_settings").(bool),
-		UpdateGlobalSettings:          d.Get("update_global_settings").(bool),
-	}
-
-	requestBody, err := json.Marshal(role)
-
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	body := bytes.NewBuffer(requestBody)
-
-	req, err := http.NewRequest("PUT", fmt.Sprintf("%[1]s/roles/%[2]s", m.(Config).api_endpoint, d.Id()), body)
+	role, err := m.(Config).client.UpdateRole(ctx, expandRole(d))
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to update role. %s", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s", m.(Config).session_token))
-
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to update role. Status Code: %v", r.StatusCode)
-		}
-
-		return diag.Errorf("Unable to update role. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	var response Role
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.Set("name", response.Name)
-	d.Set("create_credentials", response.CreateCredentials)
-	d.Set("get_credentials", response.GetCredentials)
-	d.Set("update_credentials", response.UpdateCredentials)
-	d.Set("delete_credentials", response.DeleteCredentials)
-	d.Set("create_repository", response.CreateRepository)
-	d.Set("get_repository", response.GetRepository)
-	d.Set("update_repository", response.UpdateRepository)
-	d.Set("delete_repository", response.DeleteRepository)
-	d.Set("get_user", response.GetUser)
-	d.Set("update_user", response.UpdateUser)
-	d.Set("create_user", response.CreateUser)
-	d.Set("get_role", response.GetRole)
-	d.Set("update_role", response.UpdateRole)
-	d.Set("create_role", response.CreateRole)
-	d.Set("delete_role", response.DeleteRole)
-	d.Set("get_all_templates", response.GetAllTemplates)
-	d.Set("update_all_templates", response.UpdateAllTemplates)
-	d.Set("create_templates", response.CreateTemplate)
-	d.Set("delete_all_templates", response.DeleteAllTemplates)
-	d.Set("get_all_deployments", response.GetAllDeployments)
-	d.Set("update_all_deployments", response.UpdateAllDeployments)
-	d.Set("create_deployments_all_templates", response.CreateDeploymentsAllTemplates)
-	d.Set("delete_all_deployments", response.DeleteAllDeployment)
-	d.Set("get_global_settings", response.GetGlobalSettings)
-	d.Set("update_global_settings", response.UpdateGlobalSettings)
+	setRole(d, role)
 
 	return nil
-
 }
 
 func resourceRoleDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%[1]s/roles/%[2]s", m.(Config).api_endpoint, d.Id()), nil)
-	if err != nil {
-		return diag.FromErr(err)
+	err := m.(Config).client.DeleteRole(ctx, d.Id())
+	if err != nil && !client.IsNotFound(err) {
+		return diag.Errorf("Unable to delete role. %s", err)
 	}
 
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s", m.(Config).session_token))
+	d.SetId("")
+	return nil
+}
 
-	r, err := client.Do(req)
+// listRoleNames lists the roles to import by name.
+func listRoleNames(ctx context.Context, c *client.Client) ([]namedObject, error) {
+	roles, err := c.ListRoles(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to delete role. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to delete role. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	defer r.Body.Close()
-
-	var response *Role
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return diag.FromErr(err)
+	objects := make([]namedObject, len(roles))
+	for i, role := range roles {
+		objects[i] = namedObject{ID: role.ID, Name: role.Name}
 	}
-
-	d.SetId("")
-	return nil
+	return objects, nil
 }
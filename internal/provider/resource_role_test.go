@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+func TestAccRole_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRoleDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccRoleConfig("developers", false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRoleOnServer(server, "nyno_role.test", "developers", false),
+					resource.TestCheckResourceAttrSet("nyno_role.test", "id"),
+					resource.TestCheckResourceAttr("nyno_role.test", "name", "developers"),
+					resource.TestCheckResourceAttr("nyno_role.test", "get_role", "true"),
+					resource.TestCheckResourceAttr("nyno_role.test", "delete_role", "false"),
+				),
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccRoleConfig("maintainers", true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRoleOnServer(server, "nyno_role.test", "maintainers", true),
+					resource.TestCheckResourceAttr("nyno_role.test", "name", "maintainers"),
+					resource.TestCheckResourceAttr("nyno_role.test", "delete_role", "true"),
+				),
+			},
+			{
+				ResourceName:      "nyno_role.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "nyno_role.test",
+				ImportState:       true,
+				ImportStateId:     "name:maintainers",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccRole_importByNameErrors(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRoleDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccRoleConfig("developers", false),
+			},
+			{
+				ResourceName:  "nyno_role.test",
+				ImportState:   true,
+				ImportStateId: "name:missing",
+				ExpectError:   regexp.MustCompile(`No role is named "missing"`),
+			},
+			{
+				PreConfig: func() {
+					server.PutRole(&client.Role{Name: "developers"})
+				},
+				ResourceName:  "nyno_role.test",
+				ImportState:   true,
+				ImportStateId: "name:developers",
+				ExpectError:   regexp.MustCompile(`2 roles are named "developers"`),
+			},
+			{
+				ResourceName:  "nyno_role.test",
+				ImportState:   true,
+				ImportStateId: "00000000-0000-0000-0000-000000000000",
+				ExpectError:   regexp.MustCompile(`Cannot import non-existent remote object`),
+			},
+		},
+	})
+}
+
+func TestAccRole_disappears(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckRoleDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				// A role deleted outside of Terraform is planned again.
+				Config:             testAccProviderConfig(server) + testAccRoleConfig("developers", false),
+				Check:              testAccWithID("nyno_role.test", server.DeleteRole),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccRoleConfig("developers", false),
+				Check:  testAccCheckRoleOnServer(server, "nyno_role.test", "developers", false),
+			},
+		},
+	})
+}
+
+func TestAccRole_deleteNotFound(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// The destroy at the end of the test must accept the 404.
+				Config: testAccProviderConfig(server) + testAccRoleConfig("developers", false),
+				Check: testAccWithID("nyno_role.test", func(id string) {
+					server.FailNext(http.MethodDelete, "/roles/"+id, http.StatusNotFound, "Role not found")
+				}),
+			},
+		},
+	})
+}
+
+func testAccCheckRoleOnServer(server *fakenyno.Server, address string, name string, deleteRole bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		role := server.Role(rs.Primary.ID)
+		if role == nil {
+			return fmt.Errorf("role %s not found in Nyno", rs.Primary.ID)
+		}
+		if role.Name != name {
+			return fmt.Errorf("expected role name %q, got %q", name, role.Name)
+		}
+		if role.DeleteRole != deleteRole {
+			return fmt.Errorf("expected deleteRole %v, got %v", deleteRole, role.DeleteRole)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckRoleDestroy(server *fakenyno.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "nyno_role" {
+				continue
+			}
+			if server.Role(rs.Primary.ID) != nil {
+				return fmt.Errorf("role %s still exists", rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccRoleConfig(name string, deleteRole bool) string {
+	return fmt.Sprintf(`
+resource "nyno_role" "test" {
+  name                             = %q
+  create_credentials               = false
+  get_credentials                  = true
+  update_credentials               = false
+  delete_credentials               = false
+  create_repository                = false
+  get_repository                   = true
+  update_repository                = false
+  delete_repository                = false
+  get_user                         = true
+  update_user                      = false
+  create_user                      = false
+  get_role                         = true
+  update_role                      = false
+  create_role                      = false
+  delete_role                      = %t
+  get_all_templates                = true
+  update_all_templates             = true
+  create_templates                 = true
+  delete_all_templates             = false
+  get_all_deployments              = true
+  update_all_deployments           = false
+  create_deployments_all_templates = true
+  delete_all_deployments           = false
+  get_global_settings              = true
+  update_global_settings           = false
+}
+`, name, deleteRole)
+}
@@ -1,69 +1,25 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-
 	"fmt"
-	"log"
-	"net/http"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-)
-
-type Action struct {
-	ID           string `json:"id,omitempty"`
-	Type         string `json:"type"`
-	Path         string `json:"path"`
-	SourceBranch string `json:"sourceBranch"`
-	TargetBranch string `json:"targetBranch"`
-	TemplateCode string `json:"templateCode"`
-	PullRequest  bool   `json:"pullRequest"`
-	RepositoryId string `json:"repositoryId"`
-}
-
-type Variable struct {
-	ID           string `json:"id,omitempty"`
-	Title        string `json:"title"`
-	Variable     string `json:"variable"`
-	Description  string `json:"description"`
-	Type         string `json:"type"`
-	DefaultValue string `json:"defaultValue"`
-}
-
-type Permissions struct {
-	ID          string `json:"id,omitempty"`
-	AccessLevel string `json:"accessLevel"`
-	RoleId      string `json:"roleId"`
-}
 
-type Template struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Actions     []*Action      `json:"actions"`
-	Variables   []*Variable    `json:"variables"`
-	Permissions []*Permissions `json:"permissions"`
-}
-
-type TemplateCreateRequest struct {
-	Template *Template `json:"template"`
-}
-
-type ResponseError struct {
-	Error string `json:"error"`
-}
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+)
 
-func expandActions(config []interface{}) []*Action {
-	actions := make([]*Action, 0, len(config))
+func expandActions(config []interface{}) []*client.Action {
+	actions := make([]*client.Action, 0, len(config))
 
 	for _, rawAction := range config {
 		actionConfig := rawAction.(map[string]interface{})
 
-		action := &Action{
+		action := &client.Action{
+			ID:           actionConfig["id"].(string),
 			Type:         actionConfig["type"].(string),
 			Path:         actionConfig["path"].(string),
 			SourceBranch: actionConfig["source_branch"].(string),
@@ -79,13 +35,14 @@ func expandActions(config []interface{}) []*Action {
 	return actions
 }
 
-func expandVariables(config []interface{}) []*Variable {
-	variables := make([]*Variable, 0, len(config))
+func expandVariables(config []interface{}) []*client.Variable {
+	variables := make([]*client.Variable, 0, len(config))
 
 	for _, rawVariable := range config {
 		variableConfig := rawVariable.(map[string]interface{})
 
-		variable := &Variable{
+		variable := &client.Variable{
+			ID:           variableConfig["id"].(string),
 			Title:        variableConfig["title"].(string),
 			Variable:     variableConfig["variable"].(string),
 			Description:  variableConfig["description"].(string),
@@ -99,13 +56,14 @@ func expandVariables(config []interface{}) []*Variable {
 	return variables
 }
 
-func expandPermissions(config []interface{}) []*Permissions {
-	permissions := make([]*Permissions, 0, len(config))
+func expandPermissions(config []interface{}) []*client.Permissions {
+	permissions := make([]*client.Permissions, 0, len(config))
 
 	for _, rawPermissions := range config {
 		permissionsConfig := rawPermissions.(map[string]interface{})
 
-		permission := &Permissions{
+		permission := &client.Permissions{
+			ID:          permissionsConfig["id"].(string),
 			AccessLevel: permissionsConfig["access_level"].(string),
 			RoleId:      permissionsConfig["role_id"].(string),
 		}
@@ -116,13 +74,161 @@ func expandPermissions(config []interface{}) []*Permissions {
 	return permissions
 }
 
+// actionKey, variableKey and permissionsKey identify a nested item whose other
+// fields changed, see withPriorIDs.
+func actionKey(config map[string]interface{}) string {
+	return fmt.Sprintf("%s\x00%s", config["repository_id"], config["path"])
+}
+
+func variableKey(config map[string]interface{}) string {
+	return config["variable"].(string)
+}
+
+func permissionsKey(config map[string]interface{}) string {
+	return config["role_id"].(string)
+}
+
+// withPriorIDs returns the configuration of the nested block key with the ID
+// each item had in the prior state, so that the API updates existing items
+// instead of replacing them. An item keeps the ID of an identical prior item,
+// otherwise of a prior item with the same key, otherwise it is sent without
+// ID as a new item. Matching on content rather than on position keeps the IDs
+// right when an item is inserted or removed in the middle of the list.
+func withPriorIDs(d *schema.ResourceData, key string, itemKey func(map[string]interface{}) string) []interface{} {
+	rawOld, rawNew := d.GetChange(key)
+	oldItems := rawOld.([]interface{})
+	newItems := rawNew.([]interface{})
+
+	claimed := make([]bool, len(oldItems))
+	ids := make([]string, len(newItems))
+	matched := make([]bool, len(newItems))
+
+	claim := func(match func(oldItem map[string]interface{}, newItem map[string]interface{}) bool) {
+		for i, rawNewItem := range newItems {
+			if matched[i] {
+				continue
+			}
+			for j, rawOldItem := range oldItems {
+				if claimed[j] {
+					continue
+				}
+				oldItem := rawOldItem.(map[string]interface{})
+				if match(oldItem, rawNewItem.(map[string]interface{})) {
+					ids[i] = oldItem["id"].(string)
+					matched[i] = true
+					claimed[j] = true
+					break
+				}
+			}
+		}
+	}
+
+	claim(func(oldItem map[string]interface{}, newItem map[string]interface{}) bool {
+		for field, value := range newItem {
+			if field != "id" && oldItem[field] != value {
+				return false
+			}
+		}
+		return true
+	})
+	claim(func(oldItem map[string]interface{}, newItem map[string]interface{}) bool {
+		return itemKey(oldItem) == itemKey(newItem)
+	})
+
+	items := make([]interface{}, 0, len(newItems))
+	for i, rawNewItem := range newItems {
+		item := map[string]interface{}{}
+		for field, value := range rawNewItem.(map[string]interface{}) {
+			item[field] = value
+		}
+		item["id"] = ids[i]
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func flattenActions(actions []*client.Action) []interface{} {
+	config := make([]interface{}, 0, len(actions))
+
+	for _, action := range actions {
+		config = append(config, map[string]interface{}{
+			"id":            action.ID,
+			"type":          action.Type,
+			"path":          action.Path,
+			"source_branch": action.SourceBranch,
+			"target_branch": action.TargetBranch,
+			"template_code": action.TemplateCode,
+			"pull_request":  action.PullRequest,
+			"repository_id": action.RepositoryId,
+		})
+	}
+
+	return config
+}
+
+func flattenVariables(variables []*client.Variable) []interface{} {
+	config := make([]interface{}, 0, len(variables))
+
+	for _, variable := range variables {
+		config = append(config, map[string]interface{}{
+			"id":            variable.ID,
+			"title":         variable.Title,
+			"variable":      variable.Variable,
+			"description":   variable.Description,
+			"type":          variable.Type,
+			"default_value": variable.DefaultValue,
+		})
+	}
+
+	return config
+}
+
+func flattenPermissions(permissions []*client.Permissions) []interface{} {
+	config := make([]interface{}, 0, len(permissions))
+
+	for _, permission := range permissions {
+		config = append(config, map[string]interface{}{
+			"id":           permission.ID,
+			"access_level": permission.AccessLevel,
+			"role_id":      permission.RoleId,
+		})
+	}
+
+	return config
+}
+
+// setTemplate stores template in the state of a nyno_template resource.
+func setTemplate(d *schema.ResourceData, template *client.Template) error {
+	d.Set("name", template.Name)
+	d.Set("description", template.Description)
+
+	if err := d.Set("action", flattenActions(template.Actions)); err != nil {
+		return err
+	}
+	if err := d.Set("variable", flattenVariables(template.Variables)); err != nil {
+		return err
+	}
+	return d.Set("permissions", flattenPermissions(template.Permissions))
+}
+
 // Resource schema definition
 func resourceTemplate() *schema.Resource {
 	return &schema.Resource{
+		Description:   "Create a nyno template.",
 		CreateContext: resourceTemplateCreate,
 		ReadContext:   resourceTemplateRead,
 		UpdateContext: resourceTemplateUpdate,
 		DeleteContext: resourceTemplateDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importByName("template", listTemplateNames),
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString, // Field type
@@ -238,8 +344,6 @@ func resourceTemplate() *schema.Resource {
 }
 
 func resourceTemplateCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
-
 	// Getting from terraform
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
@@ -248,7 +352,7 @@ func resourceTemplateCreate(ctx context.Context, d *schema.ResourceData, m inter
 	permissions := d.Get("permissions").([]interface{})
 
 	// Build Template object
-	template := &Template{
+	template := &client.Template{
 		Name:        name,
 		Description: description,
 		Actions:     expandActions(actions),
@@ -256,107 +360,52 @@ func resourceTemplateCreate(ctx context.Context, d *schema.ResourceData, m inter
 		Permissions: expandPermissions(permissions),
 	}
 
-	requestBody, err := json.Marshal(template)
-
+	response, err := m.(Config).client.CreateTemplate(ctx, template)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to create template. %s", err)
 	}
 
-	body := bytes.NewBuffer(requestBody)
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/templates", m.(Config).api_endpoint), body)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s" ,m.(Config).session_token))
-
-	log.Println("Sending http request")
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to create template. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to create template. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	var response Template
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
+	d.SetId(response.ID)
+	if err := setTemplate(d, response); err != nil {
 		return diag.FromErr(err)
 	}
 
-	d.Set("name", response.Name)
-	d.Set("description", response.Description)
-	d.Set("actions", response.Actions)
-	d.Set("variables", response.Variables)
-	d.Set("permissions", response.Permissions)
-	d.SetId(response.ID)
-
 	return nil
 }
 
 func resourceTemplateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%[1]s/templates/%[2]s", m.(Config).api_endpoint, d.Id()), nil)
-	if err != nil {
-		return diag.FromErr(err)
+	response, err := m.(Config).client.GetTemplate(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Template not found in Nyno, removing it from state")
+		d.SetId("")
+		return nil
 	}
-
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s" ,m.(Config).session_token))
-
-	r, err := client.Do(req)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to read template. %s", err)
 	}
 
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-		
-		if response == nil {
-			return diag.Errorf("Unable to read template. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to read template. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	var response Template
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
+	if err := setTemplate(d, response); err != nil {
 		return diag.FromErr(err)
 	}
 
-	d.Set("name", response.Name)
-	d.Set("description", response.Description)
-	d.Set("actions", response.Actions)
-	d.Set("variables", response.Variables)
-	d.Set("permissions", response.Permissions)
-
 	return nil
 }
 
 func resourceTemplateUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
 	// Getting from terraform
 	id := d.Id()
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
-	actions := d.Get("action").([]interface{})
-	variables := d.Get("variable").([]interface{})
-	permissions := d.Get("permissions").([]interface{})
+	actions := withPriorIDs(d, "action", actionKey)
+	variables := withPriorIDs(d, "variable", variableKey)
+	permissions := withPriorIDs(d, "permissions", permissionsKey)
 
 	// Build Template object
-	template := &Template{
+	template := &client.Template{
 		ID:          id,
 		Name:        name,
 		Description: description,
@@ -365,85 +414,40 @@ func resourceTemplateUpdate(ctx context.Context, d *schema.ResourceData, m inter
 		Permissions: expandPermissions(permissions),
 	}
 
-	requestBody, err := json.Marshal(template)
-
+	response, err := m.(Config).client.UpdateTemplate(ctx, template)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Errorf("Unable to update template. %s", err)
 	}
 
-	body := bytes.NewBuffer(requestBody)
-
-	req, err := http.NewRequest("PUT", fmt.Sprintf("%[1]s/templates/%[2]s", m.(Config).api_endpoint, d.Id()), body)
-	if err != nil {
+	if err := setTemplate(d, response); err != nil {
 		return diag.FromErr(err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s" ,m.(Config).session_token))
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to update template. Status Code: %v", r.StatusCode)
-		}
-
-		return diag.Errorf("Unable to update template. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	var response Template
-	err = json.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.Set("name", response.Name)
-	d.Set("description", response.Description)
-	d.Set("actions", response.Actions)
-	d.Set("variables", response.Variables)
-	d.Set("permissions", response.Permissions)
-
 	return nil
-
 }
 
 func resourceTemplateDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := &http.Client{Timeout: 10 * time.Second}
+	ctx = tflog.With(ctx, "nyno_resource_id", d.Id())
 
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%[1]s/templates/%[2]s", m.(Config).api_endpoint, d.Id()), nil)
-	if err != nil {
-		return diag.FromErr(err)
+	err := m.(Config).client.DeleteTemplate(ctx, d.Id())
+	if err != nil && !client.IsNotFound(err) {
+		return diag.Errorf("Unable to delete template. %s", err)
 	}
 
-	req.Header.Set("Cookie", fmt.Sprintf("next-auth.session-token=%[1]s" ,m.(Config).session_token))
-	r, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	if r.StatusCode != 200 {
-		var response *ResponseError
-		err = json.NewDecoder(r.Body).Decode(&response)
-
-		if response == nil {
-			return diag.Errorf("Unable to delete template. Status Code: %v", r.StatusCode)
-		}
-		return diag.Errorf("Unable to delete template. Status Code: %v. Message: %s", r.StatusCode, response.Error)
-	}
-
-	defer r.Body.Close()
+	d.SetId("")
+	return nil
+}
 
-	var response *Template
-	err = json.NewDecoder(r.Body).Decode(&response)
+// listTemplateNames lists the templates to import by name.
+func listTemplateNames(ctx context.Context, c *client.Client) ([]namedObject, error) {
+	templates, err := c.ListTemplates(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 
-	d.SetId("")
-	return nil
+	objects := make([]namedObject, len(templates))
+	for i, template := range templates {
+		objects[i] = namedObject{ID: template.ID, Name: template.Name}
+	}
+	return objects, nil
 }
@@ -0,0 +1,350 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/nyno-app/terraform-provider-nyno/internal/client"
+	"github.com/nyno-app/terraform-provider-nyno/internal/fakenyno"
+)
+
+const testAccRepositoryURL = "https://github.com/nyno-app/example"
+
+func TestAccTemplate_basic(t *testing.T) {
+	server := testAccServer(t)
+	repository := server.AddRepository("example", testAccRepositoryURL, true)
+	importConfig := testAccProviderConfig(server) + testAccTemplateResourceConfig("service-v2", "develop", strconv.Quote(repository.ID))
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckTemplateDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTemplateOnServer(server, "nyno_template.test", "service", "main"),
+					resource.TestCheckResourceAttrSet("nyno_template.test", "id"),
+					resource.TestCheckResourceAttr("nyno_template.test", "name", "service"),
+					resource.TestCheckResourceAttr("nyno_template.test", "action.#", "1"),
+					resource.TestCheckResourceAttr("nyno_template.test", "action.0.target_branch", "main"),
+					resource.TestCheckResourceAttrPair("nyno_template.test", "action.0.repository_id", "data.nyno_repository.test", "id"),
+					resource.TestCheckResourceAttr("nyno_template.test", "variable.#", "2"),
+					resource.TestCheckResourceAttr("nyno_template.test", "variable.1.variable", "owner"),
+					resource.TestCheckResourceAttrSet("nyno_template.test", "action.0.id"),
+					resource.TestCheckResourceAttrSet("nyno_template.test", "variable.0.id"),
+				),
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service-v2", "develop"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTemplateOnServer(server, "nyno_template.test", "service-v2", "develop"),
+					resource.TestCheckResourceAttr("nyno_template.test", "name", "service-v2"),
+					resource.TestCheckResourceAttr("nyno_template.test", "action.0.target_branch", "develop"),
+				),
+			},
+			{
+				Config:            importConfig,
+				ResourceName:      "nyno_template.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config:            importConfig,
+				ResourceName:      "nyno_template.test",
+				ImportState:       true,
+				ImportStateId:     "name:service-v2",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccTemplate_drift(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckTemplateDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+			},
+			{
+				// An edit made in the Nyno UI shows up as a diff.
+				PreConfig: func() {
+					testAccEditTemplates(server, func(template *client.Template) {
+						template.Actions[0].TargetBranch = "edited-in-ui"
+						template.Variables = template.Variables[:1]
+					})
+				},
+				Config:             testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTemplateOnServer(server, "nyno_template.test", "service", "main"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccTemplate_importByNameErrors(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckTemplateDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+			},
+			{
+				ResourceName:  "nyno_template.test",
+				ImportState:   true,
+				ImportStateId: "name:missing",
+				ExpectError:   regexp.MustCompile(`No template is named "missing"`),
+			},
+			{
+				PreConfig: func() {
+					server.PutTemplate(&client.Template{Name: "service", Description: "A copy made in the UI"})
+				},
+				ResourceName:  "nyno_template.test",
+				ImportState:   true,
+				ImportStateId: "name:service",
+				ExpectError:   regexp.MustCompile(`2 templates are named "service"`),
+			},
+		},
+	})
+}
+
+func TestAccTemplate_disappears(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckTemplateDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				// A template deleted outside of Terraform is planned again.
+				Config:             testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check:              testAccWithID("nyno_template.test", server.DeleteTemplate),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check:  testAccCheckTemplateOnServer(server, "nyno_template.test", "service", "main"),
+			},
+		},
+	})
+}
+
+func TestAccTemplate_deleteNotFound(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// The destroy at the end of the test must accept the 404.
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check: testAccWithID("nyno_template.test", func(id string) {
+					server.FailNext(http.MethodDelete, "/templates/"+id, http.StatusNotFound, "Template not found")
+				}),
+			},
+		},
+	})
+}
+
+func TestAccTemplate_stableNestedIDs(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	ids := map[string]string{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckTemplateDestroy(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCaptureAttr("nyno_template.test", "action.0.id", ids),
+					testAccCaptureAttr("nyno_template.test", "variable.0.id", ids),
+					testAccCaptureAttr("nyno_template.test", "variable.1.id", ids),
+				),
+			},
+			{
+				// A variable inserted first and a changed action keep the other
+				// items, and the action, on their server side identity.
+				Config: testAccProviderConfig(server) + strings.Replace(
+					testAccTemplateConfig("service", "develop"),
+					"  variable {",
+					"  variable {\n    title    = \"Environment\"\n    variable = \"environment\"\n    type     = \"string\"\n  }\n\n  variable {",
+					1,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("nyno_template.test", "variable.#", "3"),
+					resource.TestCheckResourceAttr("nyno_template.test", "variable.0.variable", "environment"),
+					testAccCheckAttrEquals("nyno_template.test", "action.0.id", ids, "action.0.id"),
+					testAccCheckAttrEquals("nyno_template.test", "variable.1.id", ids, "variable.0.id"),
+					testAccCheckAttrEquals("nyno_template.test", "variable.2.id", ids, "variable.1.id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccTemplateDataSource_basic(t *testing.T) {
+	server := testAccServer(t)
+	server.AddRepository("example", testAccRepositoryURL, true)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + testAccTemplateConfig("service", "main") + `
+data "nyno_template" "test" {
+  id = nyno_template.test.id
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nyno_template.test", "name", "service"),
+					resource.TestCheckResourceAttr("data.nyno_template.test", "actions.0.target_branch", "main"),
+					resource.TestCheckResourceAttr("data.nyno_template.test", "variables.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCaptureAttr stores the value of attribute of address in values.
+func testAccCaptureAttr(address string, attribute string, values map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		value, ok := rs.Primary.Attributes[attribute]
+		if !ok || value == "" {
+			return fmt.Errorf("%s.%s is not set", address, attribute)
+		}
+		values[attribute] = value
+
+		return nil
+	}
+}
+
+// testAccCheckAttrEquals checks attribute of address against the value stored
+// under key by testAccCaptureAttr.
+func testAccCheckAttrEquals(address string, attribute string, values map[string]string, key string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		return resource.TestCheckResourceAttr(address, attribute, values[key])(s)
+	}
+}
+
+// testAccEditTemplates changes every template of server, as an edit made in
+// the Nyno UI would.
+func testAccEditTemplates(server *fakenyno.Server, edit func(*client.Template)) {
+	for _, template := range server.Templates() {
+		edit(template)
+		server.PutTemplate(template)
+	}
+}
+
+func testAccCheckTemplateOnServer(server *fakenyno.Server, address string, name string, targetBranch string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[address]
+		if !ok {
+			return fmt.Errorf("%s not found in state", address)
+		}
+
+		template := server.Template(rs.Primary.ID)
+		if template == nil {
+			return fmt.Errorf("template %s not found in Nyno", rs.Primary.ID)
+		}
+		if template.Name != name {
+			return fmt.Errorf("expected template name %q, got %q", name, template.Name)
+		}
+		if len(template.Actions) != 1 || template.Actions[0].TargetBranch != targetBranch {
+			return fmt.Errorf("expected one action targeting %q, got %+v", targetBranch, template.Actions)
+		}
+		if len(template.Variables) != 2 {
+			return fmt.Errorf("expected 2 variables, got %d", len(template.Variables))
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckTemplateDestroy(server *fakenyno.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "nyno_template" {
+				continue
+			}
+			if server.Template(rs.Primary.ID) != nil {
+				return fmt.Errorf("template %s still exists", rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccTemplateConfig(name string, targetBranch string) string {
+	return fmt.Sprintf(`
+data "nyno_repository" "test" {
+  url = %q
+}
+`, testAccRepositoryURL) + testAccTemplateResourceConfig(name, targetBranch, "data.nyno_repository.test.id")
+}
+
+// testAccTemplateResourceConfig is the template of testAccTemplateConfig with
+// repositoryID as the expression of its action's repository. Import steps use
+// a literal ID, as ImportStateVerify trips over data sources in the config.
+func testAccTemplateResourceConfig(name string, targetBranch string, repositoryID string) string {
+	return fmt.Sprintf(`
+resource "nyno_template" "test" {
+  name        = %q
+  description = "Creates a service"
+
+  variable {
+    title    = "Service name"
+    variable = "name"
+    type     = "string"
+  }
+
+  variable {
+    title         = "Owner"
+    variable      = "owner"
+    description   = "Team owning the service"
+    type          = "string"
+    default_value = "platform"
+  }
+
+  action {
+    type          = "createFile"
+    source_branch = "main"
+    target_branch = %q
+    path          = "services/service.yaml"
+    template_code = base64encode("name: {{ name }}")
+    pull_request  = true
+    repository_id = %s
+  }
+}
+`, name, targetBranch, repositoryID)
+}
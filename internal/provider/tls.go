@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// buildTLSConfig returns the TLS settings of the provider, or nil when none of
+// the TLS attributes is set so that Go defaults apply.
+func buildTLSConfig(ctx context.Context, d *schema.ResourceData) (*tls.Config, diag.Diagnostics) {
+	caCertFile := d.Get("ca_cert_file").(string)
+	caCertPEM := d.Get("ca_cert_pem").(string)
+	clientCert := d.Get("client_cert").(string)
+	clientKey := d.Get("client_key").(string)
+	insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
+
+	if caCertFile == "" && caCertPEM == "" && clientCert == "" && clientKey == "" && !insecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertFile != "" {
+		path, err := expandHome(caCertFile)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, diag.Errorf("Unable to read ca_cert_file: %s", err)
+		}
+		caCertPEM = string(pem)
+	}
+
+	if caCertPEM != "" {
+		// The CA bundle is added to the system roots, so that a public
+		// endpoint keeps working when it is set.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caCertPEM)) {
+			return nil, diag.Errorf("No PEM encoded certificate found in the CA bundle set with ca_cert_file or ca_cert_pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if clientCert != "" || clientKey != "" {
+		if clientCert == "" || clientKey == "" {
+			return nil, diag.Errorf("client_cert and client_key must be set together")
+		}
+
+		certPEM, err := readPEM(clientCert)
+		if err != nil {
+			return nil, diag.Errorf("Unable to read client_cert: %s", err)
+		}
+		keyPEM, err := readPEM(clientKey)
+		if err != nil {
+			return nil, diag.Errorf("Unable to read client_key: %s", err)
+		}
+
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, diag.Errorf("Invalid client_cert or client_key: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if insecureSkipVerify {
+		tflog.Warn(ctx, "insecure_skip_verify is set, the certificate of the Nyno API will not be verified")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns value when it holds PEM content, otherwise the content of the
+// file it points to.
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	path, err := expandHome(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testCertificate returns a self-signed client certificate and its key, both
+// PEM encoded.
+func testCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func testClearTLSEnv(t *testing.T) {
+	for _, name := range []string{"NYNO_CA_CERT_FILE", "NYNO_CLIENT_CERT", "NYNO_CLIENT_KEY", "NYNO_INSECURE_SKIP_VERIFY"} {
+		t.Setenv(name, "")
+	}
+}
+
+func testBuildTLSConfig(t *testing.T, raw map[string]interface{}) (*tls.Config, string) {
+	t.Helper()
+
+	tlsConfig, diags := buildTLSConfig(context.Background(), schema.TestResourceDataRaw(t, Provider("test").Schema, raw))
+	if diags.HasError() {
+		return nil, diags[0].Summary
+	}
+	return tlsConfig, ""
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	testCredentialsHome(t)
+	testClearTLSEnv(t)
+
+	certPEM, keyPEM := testCertificate(t)
+	dir := t.TempDir()
+	for name, content := range map[string]string{"ca.pem": certPEM, "cert.pem": certPEM, "key.pem": keyPEM, "bad.pem": "not a certificate"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		raw  map[string]interface{}
+		// err is part of the expected error, empty if the settings are
+		// accepted.
+		err   string
+		check func(*tls.Config) bool
+	}{
+		{
+			name:  "no TLS attribute",
+			raw:   map[string]interface{}{},
+			check: func(c *tls.Config) bool { return c == nil },
+		},
+		{
+			name:  "CA file",
+			raw:   map[string]interface{}{"ca_cert_file": filepath.Join(dir, "ca.pem")},
+			check: func(c *tls.Config) bool { return c.RootCAs != nil && c.MinVersion == tls.VersionTLS12 },
+		},
+		{
+			name:  "inline CA",
+			raw:   map[string]interface{}{"ca_cert_pem": certPEM},
+			check: func(c *tls.Config) bool { return c.RootCAs != nil },
+		},
+		{
+			name: "CA file without a certificate",
+			raw:  map[string]interface{}{"ca_cert_file": filepath.Join(dir, "bad.pem")},
+			err:  "No PEM encoded certificate found",
+		},
+		{
+			name: "inline CA without a certificate",
+			raw:  map[string]interface{}{"ca_cert_pem": "-----BEGIN CERTIFICATE-----\nbad\n-----END CERTIFICATE-----\n"},
+			err:  "No PEM encoded certificate found",
+		},
+		{
+			name: "missing CA file",
+			raw:  map[string]interface{}{"ca_cert_file": filepath.Join(dir, "missing.pem")},
+			err:  "Unable to read ca_cert_file",
+		},
+		{
+			name:  "client certificate files",
+			raw:   map[string]interface{}{"client_cert": filepath.Join(dir, "cert.pem"), "client_key": filepath.Join(dir, "key.pem")},
+			check: func(c *tls.Config) bool { return len(c.Certificates) == 1 },
+		},
+		{
+			name:  "inline client certificate",
+			raw:   map[string]interface{}{"client_cert": certPEM, "client_key": keyPEM},
+			check: func(c *tls.Config) bool { return len(c.Certificates) == 1 },
+		},
+		{
+			name: "client_cert without client_key",
+			raw:  map[string]interface{}{"client_cert": certPEM},
+			err:  "client_cert and client_key must be set together",
+		},
+		{
+			name: "client_key without client_cert",
+			raw:  map[string]interface{}{"client_key": keyPEM},
+			err:  "client_cert and client_key must be set together",
+		},
+		{
+			name: "missing client certificate file",
+			raw:  map[string]interface{}{"client_cert": filepath.Join(dir, "missing.pem"), "client_key": keyPEM},
+			err:  "Unable to read client_cert",
+		},
+		{
+			name: "mismatched client certificate and key",
+			raw:  map[string]interface{}{"client_cert": certPEM, "client_key": certPEM},
+			err:  "Invalid client_cert or client_key",
+		},
+		{
+			name:  "insecure_skip_verify",
+			raw:   map[string]interface{}{"insecure_skip_verify": true},
+			check: func(c *tls.Config) bool { return c.InsecureSkipVerify && c.RootCAs == nil },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tlsConfig, err := testBuildTLSConfig(t, tc.raw)
+
+			if tc.err != "" {
+				if !strings.Contains(err, tc.err) {
+					t.Fatalf("expected an error containing %q, got %q", tc.err, err)
+				}
+				return
+			}
+			if err != "" {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !tc.check(tlsConfig) {
+				t.Fatalf("unexpected TLS config: %+v", tlsConfig)
+			}
+		})
+	}
+}
+
+func TestReadPEM(t *testing.T) {
+	certPEM, _ := testCertificate(t)
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := ioutil.WriteFile(path, []byte(certPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leading blank lines, as left by a heredoc, still make PEM content.
+	for _, value := range []string{certPEM, "\n  " + certPEM, path} {
+		data, err := readPEM(value)
+		if err != nil || strings.TrimSpace(string(data)) != strings.TrimSpace(certPEM) {
+			t.Errorf("%.20q: expected the certificate, got %.20q, %v", value, data, err)
+		}
+	}
+
+	if _, err := readPEM(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+// TestProviderConfigure_mutualTLS configures the provider for an API that
+// requires a client certificate and is served with a certificate only trusted
+// through ca_cert_pem: both the login and a resource call have to succeed.
+func TestProviderConfigure_mutualTLS(t *testing.T) {
+	testCredentialsHome(t)
+	testClearTLSEnv(t)
+
+	certPEM, keyPEM := testCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(certPEM))
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/auth/credentials":
+			w.Write([]byte(`{"sessionToken": "session"}`))
+		case "/roles/1":
+			w.Write([]byte(`{"id": "1", "name": "developers"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	d := schema.TestResourceDataRaw(t, Provider("test").Schema, map[string]interface{}{
+		"api_endpoint":  server.URL,
+		"organization":  "nyno",
+		"username":      "nyno-bot",
+		"password":      "password",
+		"session_cache": false,
+		"ca_cert_pem":   string(serverCertPEM),
+		"client_cert":   certPEM,
+		"client_key":    keyPEM,
+	})
+
+	m, diags := providerConfigure(context.Background(), d, "terraform-provider-nyno/test")
+	if diags.HasError() {
+		t.Fatalf("configure: %v", diags)
+	}
+	if _, err := m.(Config).client.GetRole(context.Background(), "1"); err != nil {
+		t.Fatalf("get role: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(requests, ", ") != "POST /auth/credentials, GET /roles/1" {
+		t.Fatalf("expected a login and a role read over mutual TLS, got %v", requests)
+	}
+}
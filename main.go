@@ -7,10 +7,13 @@ import (
 	"github.com/nyno-app/terraform-provider-nyno/internal/provider"
 )
 
+// version is set by goreleaser through -X main.version.
+var version string = "dev"
+
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: func() *schema.Provider {
-			return provider.Provider()
+			return provider.Provider(version)
 		},
 	})
 }